@@ -14,6 +14,7 @@ import (
 	"go-backend/pkg/database"
 	"go-backend/pkg/github"
 	"go-backend/pkg/hackernews"
+	"go-backend/pkg/portfolio"
 	"go-backend/pkg/rss"
 	"go-backend/pkg/tickers"
 )
@@ -73,7 +74,7 @@ func (s *JobScheduler) runJob(job *Job) {
 	}
 }
 
-func scheduledJobs(ghHandler *github.Handler, hnHandler *hackernews.Handler, rssHandler *rss.Handler) {
+func scheduledJobs(ghHandler *github.Handler, hnHandler *hackernews.Handler, tickerHandler *tickers.Handler, portfolioHandler *portfolio.Handler, rssHandler *rss.Handler) {
 	scheduler := NewJobScheduler()
 
 	scheduler.AddJob("GitHub Trending", time.Hour, func() error {
@@ -86,6 +87,17 @@ func scheduledJobs(ghHandler *github.Handler, hnHandler *hackernews.Handler, rss
 		return err
 	})
 
+	scheduler.AddJob("HackerNews Hiring", 6*time.Hour, func() error {
+		_, err := hnHandler.FetchHiringPosts()
+		return err
+	})
+
+	// Refresh quotes and evaluate alerts
+	tickerHandler.AddToJobScheduler(scheduler.AddJob)
+
+	// Daily portfolio value, if enabled
+	portfolioHandler.AddToJobScheduler(scheduler.AddJob)
+
 	// Add RSS feed job
 	rssHandler.AddToJobScheduler(scheduler.AddJob)
 
@@ -142,11 +154,14 @@ func main() {
 	tickerHandler := tickers.NewHandler()
 	tickerHandler.RegisterRoutes(app)
 
+	portfolioHandler := portfolio.NewHandler(tickerHandler)
+	portfolioHandler.RegisterRoutes(app)
+
 	// Initialize RSS handler and register routes
 	rssHandler := rss.NewHandler()
 	rssHandler.RegisterRoutes(app)
 
-	go scheduledJobs(ghHandler, hnHandler, rssHandler)
+	go scheduledJobs(ghHandler, hnHandler, tickerHandler, portfolioHandler, rssHandler)
 
 	log.Printf("Server starting on port %s\n", port)
 	log.Fatal(app.Listen(":" + port))
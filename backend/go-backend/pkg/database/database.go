@@ -2,21 +2,24 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const databasePath = "./data/today.db"
+
 var db *sql.DB
 
 func Initialize() error {
 	var err error
 
-	db, err = sql.Open("sqlite3", "./data/today.db")
+	db, err = sql.Open("sqlite3", databasePath)
 	if err != nil {
 		return err
 	}
-	log.Printf("Connected to database: %s", db)
+	log.Printf("Connected to database: %s", databasePath)
 
 	// Test the connection
 	if err = db.Ping(); err != nil {
@@ -69,6 +72,58 @@ func Initialize() error {
 			title TEXT,
 			type TEXT,
 			url TEXT UNIQUE,  -- Added UNIQUE constraint on URL
+			rank INTEGER,     -- Position on the HN front page when fetched
+			best_rank INTEGER,  -- Highest position the story ever reached
+			first_seen_at TIMESTAMP,  -- Kept across refreshes, unlike created_at
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Databases created before these columns existed need them added
+	if err = EnsureColumn("hackernews_stories", "rank", "INTEGER"); err != nil {
+		return err
+	}
+	if err = EnsureColumn("hackernews_stories", "best_rank", "INTEGER"); err != nil {
+		return err
+	}
+	if err = EnsureColumn("hackernews_stories", "first_seen_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+
+	// Older rows only know when they were last refreshed
+	_, err = db.Exec(`
+		UPDATE hackernews_stories
+		SET first_seen_at = COALESCE(first_seen_at, created_at),
+		    best_rank = COALESCE(best_rank, rank)
+		WHERE first_seen_at IS NULL OR best_rank IS NULL
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_hackernews_stories_first_seen ON hackernews_stories(first_seen_at)`)
+	if err != nil {
+		return err
+	}
+
+	// Create table for job postings parsed from "Who is hiring?" threads
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS hackernews_hiring (
+			id INTEGER PRIMARY KEY,  -- Comment ID
+			thread_id INTEGER NOT NULL,
+			by TEXT,
+			time INTEGER,
+			company TEXT,
+			location TEXT,
+			remote BOOLEAN,
+			onsite BOOLEAN,
+			roles JSON,
+			salary TEXT,
+			url TEXT,
+			text TEXT,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
@@ -79,6 +134,44 @@ func Initialize() error {
 	return nil
 }
 
+// EnsureColumn adds a column to an existing table if it is missing.
+// CREATE TABLE IF NOT EXISTS leaves old tables untouched, so new columns
+// must be added this way for databases created by earlier versions.
+func EnsureColumn(table, column, definition string) error {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid        int
+			name       string
+			colType    string
+			notNull    int
+			defaultVal sql.NullString
+			primaryKey int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultVal, &primaryKey); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	log.Printf("Added column %s to table %s", column, table)
+	return nil
+}
+
 func GetDB() *sql.DB {
 	return db
 }
@@ -0,0 +1,151 @@
+package hackernews
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+	_ "time/tzdata" // The runtime image ships without a zoneinfo database
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	archiveDateLayout   = "2006-01-02"
+	sqliteTimeLayout    = "2006-01-02 15:04:05"
+	defaultArchiveLimit = 30
+	maxArchiveLimit     = 100
+)
+
+// ArchiveDay summarizes the stories first seen on one calendar date
+type ArchiveDay struct {
+	Date    string `json:"date"`
+	Stories int    `json:"stories"`
+}
+
+// loadLocation resolves the ?tz= query parameter, defaulting to UTC
+func loadLocation(c *fiber.Ctx) (*time.Location, error) {
+	tz := c.Query("tz")
+	if tz == "" {
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", tz)
+	}
+	return loc, nil
+}
+
+// GetDay returns the highest-ranked stories first seen on a date in the
+// requested timezone, e.g. /hackernews/day/2026-10-16?tz=Europe/Berlin
+func (h *Handler) GetDay(c *fiber.Ctx) error {
+	loc, err := loadLocation(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	day, err := time.ParseInLocation(archiveDateLayout, c.Params("date"), loc)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", c.Params("date")),
+		})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", strconv.Itoa(defaultArchiveLimit)))
+	if err != nil || limit <= 0 || limit > maxArchiveLimit {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid limit, expected 1-%d", maxArchiveLimit),
+		})
+	}
+
+	// first_seen_at is stored in UTC, so convert the local day bounds
+	start := day.UTC().Format(sqliteTimeLayout)
+	end := day.AddDate(0, 0, 1).UTC().Format(sqliteTimeLayout)
+
+	db := database.GetDB()
+	rows, err := db.Query(`
+		SELECT id, by, descendants, score, time, title, type, url, COALESCE(best_rank, rank, 0)
+		FROM hackernews_stories
+		WHERE first_seen_at >= ? AND first_seen_at < ?
+		ORDER BY COALESCE(best_rank, rank) ASC, score DESC
+		LIMIT ?
+	`, start, end, limit)
+	if err != nil {
+		log.Printf("[HackerNews Archive] Failed to query stories for %s: %v", c.Params("date"), err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to load archive: %v", err),
+		})
+	}
+	defer rows.Close()
+
+	stories := make([]Story, 0, limit)
+	for rows.Next() {
+		var story Story
+		err := rows.Scan(
+			&story.ID,
+			&story.By,
+			&story.Descendants,
+			&story.Score,
+			&story.Time,
+			&story.Title,
+			&story.Type,
+			&story.URL,
+			&story.Rank,
+		)
+		if err != nil {
+			log.Printf("[HackerNews Archive] Failed to scan story from database: %v", err)
+			continue
+		}
+		stories = append(stories, story)
+	}
+
+	return c.JSON(stories)
+}
+
+// GetDays lists the dates, in the requested timezone, that have archived
+// stories, newest first
+func (h *Handler) GetDays(c *fiber.Ctx) error {
+	loc, err := loadLocation(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	db := database.GetDB()
+	rows, err := db.Query(`
+		SELECT first_seen_at
+		FROM hackernews_stories
+		WHERE first_seen_at IS NOT NULL
+	`)
+	if err != nil {
+		log.Printf("[HackerNews Archive] Failed to query archive dates: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to load archive dates: %v", err),
+		})
+	}
+	defer rows.Close()
+
+	// Grouping happens here rather than in SQL because the UTC offset
+	// of the requested timezone can change with daylight saving time
+	counts := make(map[string]int)
+	for rows.Next() {
+		var firstSeen time.Time
+		if err := rows.Scan(&firstSeen); err != nil {
+			log.Printf("[HackerNews Archive] Failed to scan date from database: %v", err)
+			continue
+		}
+		counts[firstSeen.In(loc).Format(archiveDateLayout)]++
+	}
+
+	days := make([]ArchiveDay, 0, len(counts))
+	for date, count := range counts {
+		days = append(days, ArchiveDay{Date: date, Stories: count})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date > days[j].Date
+	})
+
+	return c.JSON(days)
+}
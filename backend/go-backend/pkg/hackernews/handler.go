@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"os"
 	"time"
 
 	"go-backend/pkg/database"
@@ -21,16 +22,46 @@ const (
 
 type Handler struct {
 	client *http.Client
+	search *SearchClient
 }
 
 func NewHandler() *Handler {
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+
+	// Allow pointing search at a mirror or local stub
+	searchBaseURL := os.Getenv("HN_SEARCH_URL")
+	if searchBaseURL == "" {
+		searchBaseURL = DefaultSearchBaseURL
+	}
+
 	return &Handler{
-		client: &http.Client{
-			Timeout: 10 * time.Second,
-		},
+		client: client,
+		search: NewSearchClient(searchBaseURL, client),
 	}
 }
 
+// fetchJSON fetches a HackerNews API URL and decodes the JSON response into v
+func (h *Handler) fetchJSON(url string, v interface{}) error {
+	resp, err := h.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request to %s returned status %s", url, resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, v)
+}
+
 // FetchTopStories fetches top stories from HackerNews API and stores them in the database
 func (h *Handler) FetchTopStories() ([]Story, error) {
 	// Get top story IDs
@@ -60,7 +91,7 @@ func (h *Handler) FetchTopStories() ([]Story, error) {
 	stored := 0
 
 	// Get details for top 10 stories
-	for _, id := range storyIDs[:10] {
+	for i, id := range storyIDs[:10] {
 		storyURL := fmt.Sprintf(hackerNewsStoryURL, id)
 		resp, err := h.client.Get(storyURL)
 		if err != nil {
@@ -81,12 +112,16 @@ func (h *Handler) FetchTopStories() ([]Story, error) {
 			log.Printf("[HackerNews] Failed to parse story %d: %v", id, err)
 			continue
 		}
+		story.Rank = i + 1
 
-		// Store story in database
+		// Store story in database, keeping when it was first seen and
+		// the best rank it reached across refreshes
 		_, err = db.Exec(`
 			INSERT OR REPLACE INTO hackernews_stories 
-			(id, by, descendants, score, time, title, type, url)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			(id, by, descendants, score, time, title, type, url, rank, best_rank, first_seen_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?,
+				MIN(?, COALESCE((SELECT best_rank FROM hackernews_stories WHERE id = ?), ?)),
+				COALESCE((SELECT first_seen_at FROM hackernews_stories WHERE id = ?), CURRENT_TIMESTAMP))
 		`,
 			story.ID,
 			story.By,
@@ -96,6 +131,9 @@ func (h *Handler) FetchTopStories() ([]Story, error) {
 			story.Title,
 			story.Type,
 			story.URL,
+			story.Rank,
+			story.Rank, story.ID, story.Rank, // best_rank
+			story.ID, // first_seen_at
 		)
 		if err != nil {
 			log.Printf("[HackerNews] Failed to store story %d in database: %v", id, err)
@@ -114,14 +152,23 @@ func (h *Handler) FetchTopStories() ([]Story, error) {
 	return stories, nil
 }
 
+// GetTopStories serves the front page in upstream rank order by default.
+// The order can be changed with ?sort=rank|score|gravity|comments.
 func (h *Handler) GetTopStories(c *fiber.Ctx) error {
+	order := c.Query("sort", SortRank)
+	if !isValidSort(order) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid sort %q, expected one of: rank, score, gravity, comments", order),
+		})
+	}
+
 	// Try to get stories from database first
 	db := database.GetDB()
 	rows, err := db.Query(`
-		SELECT id, by, descendants, score, time, title, type, url
+		SELECT id, by, descendants, score, time, title, type, url, COALESCE(rank, 0)
 		FROM hackernews_stories
 		WHERE created_at >= datetime('now', '-5 minutes')
-		ORDER BY score DESC
+		ORDER BY rank IS NULL, rank ASC
 		LIMIT 10
 	`)
 	if err == nil {
@@ -139,6 +186,7 @@ func (h *Handler) GetTopStories(c *fiber.Ctx) error {
 				&story.Title,
 				&story.Type,
 				&story.URL,
+				&story.Rank,
 			)
 			if err != nil {
 				log.Printf("[HackerNews] Failed to scan story from database: %v", err)
@@ -149,6 +197,7 @@ func (h *Handler) GetTopStories(c *fiber.Ctx) error {
 
 		if len(stories) > 0 {
 			log.Printf("[HackerNews] Cache hit: Returned %d stories from database", len(stories))
+			sortStories(stories, order, time.Now())
 			return c.JSON(stories)
 		}
 	}
@@ -162,6 +211,7 @@ func (h *Handler) GetTopStories(c *fiber.Ctx) error {
 		})
 	}
 
+	sortStories(stories, order, time.Now())
 	return c.JSON(stories)
 }
 
@@ -170,10 +220,18 @@ func (h *Handler) RegisterRoutes(app *fiber.App) {
 		Next: func(c *fiber.Ctx) bool {
 			return c.Query("refresh") == "true"
 		},
+		// Key on the full URL so each ?sort= variant is cached separately
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return c.OriginalURL()
+		},
 		Expiration:   5 * time.Minute,
 		CacheControl: true,
 	}
 
 	app.Get("/hackernews/top", cache.New(cacheConfig), h.GetTopStories)
+	app.Get("/hackernews/hiring", cache.New(cacheConfig), h.GetHiringPosts)
+	app.Get("/hackernews/search", cache.New(cacheConfig), h.SearchStories)
+	app.Get("/hackernews/days", cache.New(cacheConfig), h.GetDays)
+	app.Get("/hackernews/day/:date", cache.New(cacheConfig), h.GetDay)
 	log.Printf("[HackerNews] Routes registered with %v cache expiration", cacheConfig.Expiration)
 }
@@ -0,0 +1,397 @@
+package hackernews
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go-backend/pkg/database"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	hackerNewsUserURL = "https://hacker-news.firebaseio.com/v0/user/%s.json"
+
+	// The monthly threads are posted by this account
+	hiringUser        = "whoishiring"
+	hiringTitlePrefix = "Ask HN: Who is hiring?"
+
+	// How many of the account's latest submissions to check for the thread
+	hiringSubmissionsToScan = 10
+
+	// Number of comments fetched in parallel
+	hiringFetchWorkers = 8
+)
+
+var (
+	salaryRegex   = regexp.MustCompile(`(?i)(?:[$€£]\s?\d[\d,.]*\s?k?|\d[\d,.]*\s?k?\s?(?:usd|eur|gbp))(?:\s?(?:-|–|to)\s?(?:[$€£]\s?)?\d[\d,.]*\s?k?(?:\s?(?:usd|eur|gbp))?)?(?:\s?(?:\+\s?equity|/\s?(?:yr|year|hr|hour)))?`)
+	urlRegex      = regexp.MustCompile(`https?://[^\s|<>"]+`)
+	remoteRegex   = regexp.MustCompile(`(?i)\bremote\b`)
+	noRemoteRegex = regexp.MustCompile(`(?i)\b(?:no|not)\s+remote\b`)
+	onsiteRegex   = regexp.MustCompile(`(?i)\b(?:on-?site|in[- ]office|in[- ]person|hybrid)\b`)
+	roleRegex     = regexp.MustCompile(`(?i)\b(?:engineers?|developers?|programmers?|scientists?|designers?|managers?|architects?|analysts?|researchers?|sre|devops|swe|cto|vp|head of|lead|founding|intern(?:ship)?s?|product|frontend|front-end|backend|back-end|full[- ]?stack|data|ml|ai)\b`)
+	jobTypeRegex  = regexp.MustCompile(`(?i)^(?:full[- ]?time|part[- ]?time|contract(?:or)?|permanent|intern(?:ship)?|ft|pt|visa(?: sponsorship)?|h1b)(?:\s*[,/&]\s*(?:full[- ]?time|part[- ]?time|contract(?:or)?|permanent|intern(?:ship)?|ft|pt))*$`)
+)
+
+// fetchItem fetches a single item from the HackerNews API
+func (h *Handler) fetchItem(id int) (*Item, error) {
+	var item Item
+	if err := h.fetchJSON(fmt.Sprintf(hackerNewsStoryURL, id), &item); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// findLatestHiringThread looks through the whoishiring account's recent
+// submissions for the newest "Who is hiring?" thread
+func (h *Handler) findLatestHiringThread() (*Item, error) {
+	var user User
+	if err := h.fetchJSON(fmt.Sprintf(hackerNewsUserURL, hiringUser), &user); err != nil {
+		return nil, fmt.Errorf("failed to fetch %s submissions: %w", hiringUser, err)
+	}
+
+	submitted := user.Submitted
+	if len(submitted) > hiringSubmissionsToScan {
+		submitted = submitted[:hiringSubmissionsToScan]
+	}
+
+	for _, id := range submitted {
+		item, err := h.fetchItem(id)
+		if err != nil {
+			log.Printf("[HackerNews Hiring] Failed to fetch submission %d: %v", id, err)
+			continue
+		}
+		if strings.HasPrefix(item.Title, hiringTitlePrefix) {
+			return item, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no %q thread found in the latest %d submissions", hiringTitlePrefix, len(submitted))
+}
+
+// FetchHiringPosts finds the latest "Who is hiring?" thread, parses any
+// top-level comments not yet stored and saves them to the database
+func (h *Handler) FetchHiringPosts() ([]HiringPost, error) {
+	thread, err := h.findLatestHiringThread()
+	if err != nil {
+		log.Printf("[HackerNews Hiring] %v", err)
+		return nil, err
+	}
+	log.Printf("[HackerNews Hiring] Found thread %d %q with %d top-level comments", thread.ID, thread.Title, len(thread.Kids))
+
+	db := database.GetDB()
+	known := make(map[int]bool)
+	rows, err := db.Query(`SELECT id FROM hackernews_hiring WHERE thread_id = ?`, thread.ID)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err == nil {
+			known[id] = true
+		}
+	}
+	rows.Close()
+
+	ids := make(chan int)
+	results := make(chan HiringPost)
+
+	var wg sync.WaitGroup
+	for i := 0; i < hiringFetchWorkers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range ids {
+				item, err := h.fetchItem(id)
+				if err != nil {
+					log.Printf("[HackerNews Hiring] Failed to fetch comment %d: %v", id, err)
+					continue
+				}
+				if item.Deleted || item.Dead || strings.TrimSpace(item.Text) == "" {
+					// Stored with an empty body so it isn't fetched again
+					// on every run; listings leave it out
+					results <- HiringPost{ID: item.ID, ThreadID: thread.ID, By: item.By, Time: item.Time}
+					continue
+				}
+				post := parseHiringComment(item.Text)
+				post.ID = item.ID
+				post.ThreadID = thread.ID
+				post.By = item.By
+				post.Time = item.Time
+				results <- post
+			}
+		}()
+	}
+
+	go func() {
+		for _, id := range thread.Kids {
+			if !known[id] {
+				ids <- id
+			}
+		}
+		close(ids)
+		wg.Wait()
+		close(results)
+	}()
+
+	var posts []HiringPost
+	stored, removed := 0, 0
+	for post := range results {
+		rolesJSON, err := json.Marshal(post.Roles)
+		if err != nil {
+			log.Printf("[HackerNews Hiring] Failed to marshal roles for comment %d: %v", post.ID, err)
+			continue
+		}
+
+		_, err = db.Exec(`
+			INSERT OR REPLACE INTO hackernews_hiring
+			(id, thread_id, by, time, company, location, remote, onsite, roles, salary, url, text)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			post.ID,
+			post.ThreadID,
+			post.By,
+			post.Time,
+			post.Company,
+			post.Location,
+			post.Remote,
+			post.Onsite,
+			rolesJSON,
+			post.Salary,
+			post.URL,
+			post.Text,
+		)
+		if err != nil {
+			log.Printf("[HackerNews Hiring] Failed to store comment %d in database: %v", post.ID, err)
+			continue
+		}
+		if post.Text == "" {
+			removed++
+			continue
+		}
+		stored++
+		posts = append(posts, post)
+	}
+
+	log.Printf("[HackerNews Hiring] Stored %d new postings (%d already known, %d deleted or empty)", stored, len(known), removed)
+	return posts, nil
+}
+
+// parseHiringComment extracts a structured posting from a comment body.
+// Postings conventionally start with a header line such as
+// "Company | Location | REMOTE | Role | $150k-$200k | https://..."
+func parseHiringComment(html string) HiringPost {
+	var post HiringPost
+
+	// HN separates paragraphs with <p> and does not wrap the first one
+	var paragraphs []string
+	var links []string
+	for _, chunk := range strings.Split(html, "<p>") {
+		doc, err := goquery.NewDocumentFromReader(strings.NewReader(chunk))
+		if err != nil {
+			continue
+		}
+		doc.Find("a").Each(func(_ int, a *goquery.Selection) {
+			if href, ok := a.Attr("href"); ok {
+				links = append(links, href)
+			}
+		})
+		if text := strings.TrimSpace(doc.Text()); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	}
+	if len(paragraphs) == 0 {
+		return post
+	}
+
+	post.Text = strings.Join(paragraphs, "\n\n")
+	header := paragraphs[0]
+
+	fields := strings.Split(header, "|")
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		if i == 0 {
+			post.Company = strings.TrimSpace(urlRegex.ReplaceAllString(field, ""))
+			continue
+		}
+
+		switch {
+		case urlRegex.MatchString(field):
+			if post.URL == "" {
+				post.URL = urlRegex.FindString(field)
+			}
+		case salaryRegex.MatchString(field) && post.Salary == "":
+			post.Salary = strings.TrimSpace(salaryRegex.FindString(field))
+		case jobTypeRegex.MatchString(field):
+			// Employment type carries no location or role information
+		case roleRegex.MatchString(field) && !remoteRegex.MatchString(field) && !onsiteRegex.MatchString(field):
+			post.Roles = append(post.Roles, splitRoles(field)...)
+		case isWorkModeOnly(field):
+			// Pure "REMOTE" / "ONSITE" fields are handled below
+		default:
+			if post.Location == "" {
+				post.Location = field
+			}
+		}
+	}
+
+	post.Remote = remoteRegex.MatchString(header) && !noRemoteRegex.MatchString(header)
+	post.Onsite = onsiteRegex.MatchString(header) || (post.Location != "" && !post.Remote)
+
+	// Fall back to the body for details missing from the header
+	if post.Salary == "" {
+		post.Salary = strings.TrimSpace(salaryRegex.FindString(post.Text))
+	}
+	if post.URL == "" && len(links) > 0 {
+		post.URL = links[0]
+	}
+	if post.URL == "" {
+		post.URL = urlRegex.FindString(post.Text)
+	}
+
+	return post
+}
+
+// isWorkModeOnly reports whether a header field only describes the work
+// arrangement, e.g. "REMOTE", "Onsite", "Remote (US)" or "Hybrid/Remote"
+func isWorkModeOnly(field string) bool {
+	rest := remoteRegex.ReplaceAllString(field, "")
+	rest = onsiteRegex.ReplaceAllString(rest, "")
+	rest = strings.Trim(rest, " ,/&-+()")
+	return rest == "" || strings.EqualFold(rest, "or") || strings.EqualFold(rest, "only")
+}
+
+// splitRoles splits a field like "Senior Engineer, Designer / PM" into roles
+func splitRoles(field string) []string {
+	var roles []string
+	for _, role := range strings.FieldsFunc(field, func(r rune) bool {
+		return r == ',' || r == '/' || r == ';'
+	}) {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// GetHiringPosts serves postings from the latest stored thread.
+// Supports ?q= (word match on company, roles, location and text) and
+// ?remote=true|false.
+func (h *Handler) GetHiringPosts(c *fiber.Ctx) error {
+	var remote *bool
+	if value := c.Query("remote"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid remote value %q", value),
+			})
+		}
+		remote = &parsed
+	}
+
+	// Only an empty table means nothing was fetched yet; filters that match
+	// nothing, or a thread of deleted comments, must not trigger a refetch
+	stored, err := hasHiringPosts()
+	if err == nil && !stored {
+		log.Printf("[HackerNews Hiring] Cache miss: Fetching latest hiring thread")
+		_, err = h.FetchHiringPosts()
+	}
+	var posts []HiringPost
+	if err == nil {
+		posts, err = h.getHiringPostsFromDB(remote)
+	}
+	if err != nil {
+		log.Printf("[HackerNews Hiring] Failed to load postings: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to load hiring posts: %v", err),
+		})
+	}
+
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		// Match whole words so "go" doesn't match "Google" or "Chicago"
+		pattern := regexp.MustCompile(`(?i)(^|[^a-z0-9])` + regexp.QuoteMeta(q) + `($|[^a-z0-9])`)
+		filtered := make([]HiringPost, 0, len(posts))
+		for _, post := range posts {
+			haystack := strings.Join(append([]string{post.Company, post.Location, post.Text}, post.Roles...), "\n")
+			if pattern.MatchString(haystack) {
+				filtered = append(filtered, post)
+			}
+		}
+		posts = filtered
+	}
+
+	return c.JSON(posts)
+}
+
+// hasHiringPosts reports whether any thread's comments were stored,
+// counting deleted and dead ones
+func hasHiringPosts() (bool, error) {
+	var exists bool
+	err := database.GetDB().QueryRow(`SELECT EXISTS (SELECT 1 FROM hackernews_hiring)`).Scan(&exists)
+	return exists, err
+}
+
+func (h *Handler) getHiringPostsFromDB(remote *bool) ([]HiringPost, error) {
+	db := database.GetDB()
+
+	query := `
+		SELECT id, thread_id, by, time, company, location, remote, onsite, roles, salary, url, text
+		FROM hackernews_hiring
+		WHERE thread_id = (SELECT MAX(thread_id) FROM hackernews_hiring) AND text != ''
+	`
+	args := []interface{}{}
+	if remote != nil {
+		query += " AND remote = ?"
+		args = append(args, *remote)
+	}
+	query += " ORDER BY time DESC"
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]HiringPost, 0)
+	for rows.Next() {
+		var post HiringPost
+		var rolesJSON sql.NullString
+		err := rows.Scan(
+			&post.ID,
+			&post.ThreadID,
+			&post.By,
+			&post.Time,
+			&post.Company,
+			&post.Location,
+			&post.Remote,
+			&post.Onsite,
+			&rolesJSON,
+			&post.Salary,
+			&post.URL,
+			&post.Text,
+		)
+		if err != nil {
+			log.Printf("[HackerNews Hiring] Failed to scan posting from database: %v", err)
+			continue
+		}
+		if rolesJSON.Valid {
+			if err := json.Unmarshal([]byte(rolesJSON.String), &post.Roles); err != nil {
+				log.Printf("[HackerNews Hiring] Failed to unmarshal roles for comment %d: %v", post.ID, err)
+			}
+		}
+		posts = append(posts, post)
+	}
+
+	return posts, rows.Err()
+}
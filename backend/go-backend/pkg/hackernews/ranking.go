@@ -0,0 +1,68 @@
+package hackernews
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Sort orders supported by the top stories endpoint
+const (
+	SortRank     = "rank"
+	SortScore    = "score"
+	SortGravity  = "gravity"
+	SortComments = "comments"
+)
+
+// gravityExponent is the time decay used by the HN front page formula
+const gravityExponent = 1.8
+
+func isValidSort(order string) bool {
+	switch order {
+	case SortRank, SortScore, SortGravity, SortComments:
+		return true
+	}
+	return false
+}
+
+// gravity computes HN's time-decayed ranking score:
+// (points - 1) / (age in hours + 2) ^ 1.8
+func gravity(story Story, now time.Time) float64 {
+	ageHours := now.Sub(time.Unix(story.Time, 0)).Hours()
+	if ageHours < 0 {
+		ageHours = 0
+	}
+	return float64(story.Score-1) / math.Pow(ageHours+2, gravityExponent)
+}
+
+// sortStories orders stories in place. Ties keep their upstream rank order.
+func sortStories(stories []Story, order string, now time.Time) {
+	var less func(a, b Story) bool
+	switch order {
+	case SortScore:
+		less = func(a, b Story) bool { return a.Score > b.Score }
+	case SortGravity:
+		less = func(a, b Story) bool { return gravity(a, now) > gravity(b, now) }
+	case SortComments:
+		less = func(a, b Story) bool { return a.Descendants > b.Descendants }
+	default:
+		// Unranked stories (rank 0) go after every ranked one
+		less = func(a, b Story) bool {
+			if a.Rank == 0 || b.Rank == 0 {
+				return a.Rank != 0 && b.Rank == 0
+			}
+			return a.Rank < b.Rank
+		}
+	}
+
+	sort.SliceStable(stories, func(i, j int) bool {
+		return less(stories[i], stories[j])
+	})
+}
+
+// sortStoriesByTime orders stories newest first
+func sortStoriesByTime(stories []Story) {
+	sort.SliceStable(stories, func(i, j int) bool {
+		return stories[i].Time > stories[j].Time
+	})
+}
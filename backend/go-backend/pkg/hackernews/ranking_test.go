@@ -0,0 +1,33 @@
+package hackernews
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSortStoriesRankPutsUnrankedLast(t *testing.T) {
+	stories := []Story{
+		{ID: 1, Rank: 0},
+		{ID: 2, Rank: 3},
+		{ID: 3, Rank: 0},
+		{ID: 4, Rank: 1},
+		{ID: 5, Rank: 2},
+	}
+
+	sortStories(stories, SortRank, time.Now())
+
+	want := []int{4, 5, 2, 1, 3}
+	for i, id := range want {
+		if stories[i].ID != id {
+			t.Fatalf("position %d: got story %d, want %d (order %v)", i, stories[i].ID, id, storyIDs(stories))
+		}
+	}
+}
+
+func storyIDs(stories []Story) []int {
+	ids := make([]int, len(stories))
+	for i, story := range stories {
+		ids[i] = story.ID
+	}
+	return ids
+}
@@ -0,0 +1,295 @@
+package hackernews
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// DefaultSearchBaseURL is the public Algolia HN Search API
+	DefaultSearchBaseURL = "https://hn.algolia.com"
+
+	searchPath       = "/api/v1/search"
+	searchByDatePath = "/api/v1/search_by_date"
+
+	defaultSearchHits = 30
+	searchDateLayout  = "2006-01-02"
+)
+
+// SearchClient queries an Algolia-compatible HN search API
+type SearchClient struct {
+	baseURL string
+	client  *http.Client
+}
+
+// SearchParams are the supported search options. Zero values are omitted.
+type SearchParams struct {
+	Query       string
+	Tags        string
+	From        time.Time
+	To          time.Time // Exclusive
+	Page        int
+	HitsPerPage int
+}
+
+// SearchHit is a single result from the search API
+type SearchHit struct {
+	ObjectID    string `json:"objectID"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Author      string `json:"author"`
+	Points      int    `json:"points"`
+	NumComments int    `json:"num_comments"`
+	CreatedAtI  int64  `json:"created_at_i"`
+}
+
+// SearchResponse is the search API response envelope
+type SearchResponse struct {
+	Hits        []SearchHit `json:"hits"`
+	NbHits      int         `json:"nbHits"`
+	Page        int         `json:"page"`
+	NbPages     int         `json:"nbPages"`
+	HitsPerPage int         `json:"hitsPerPage"`
+}
+
+// NewSearchClient creates a search client for the given base URL,
+// e.g. DefaultSearchBaseURL or a local stub server
+func NewSearchClient(baseURL string, client *http.Client) *SearchClient {
+	return &SearchClient{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+// Search returns results ordered by relevance
+func (s *SearchClient) Search(params SearchParams) (*SearchResponse, error) {
+	return s.do(searchPath, params)
+}
+
+// SearchByDate returns results ordered by date, newest first
+func (s *SearchClient) SearchByDate(params SearchParams) (*SearchResponse, error) {
+	return s.do(searchByDatePath, params)
+}
+
+func (s *SearchClient) do(path string, params SearchParams) (*SearchResponse, error) {
+	query := url.Values{}
+	query.Set("query", params.Query)
+	if params.Tags != "" {
+		query.Set("tags", params.Tags)
+	}
+
+	var filters []string
+	if !params.From.IsZero() {
+		filters = append(filters, fmt.Sprintf("created_at_i>=%d", params.From.Unix()))
+	}
+	if !params.To.IsZero() {
+		filters = append(filters, fmt.Sprintf("created_at_i<%d", params.To.Unix()))
+	}
+	if len(filters) > 0 {
+		query.Set("numericFilters", strings.Join(filters, ","))
+	}
+	if params.Page > 0 {
+		query.Set("page", strconv.Itoa(params.Page))
+	}
+	if params.HitsPerPage > 0 {
+		query.Set("hitsPerPage", strconv.Itoa(params.HitsPerPage))
+	}
+
+	requestURL := s.baseURL + path + "?" + query.Encode()
+	resp, err := s.client.Get(requestURL)
+	if err != nil {
+		return nil, fmt.Errorf("search request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("search request to %s returned status %s", requestURL, resp.Status)
+	}
+
+	var result SearchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode search response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// toStory converts a search hit into the Story shape served by the API
+func (hit SearchHit) toStory() (Story, bool) {
+	id, err := strconv.Atoi(hit.ObjectID)
+	if err != nil {
+		return Story{}, false
+	}
+	return Story{
+		By:          hit.Author,
+		Descendants: hit.NumComments,
+		ID:          id,
+		Score:       hit.Points,
+		Time:        hit.CreatedAtI,
+		Title:       hit.Title,
+		Type:        "story",
+		URL:         hit.URL,
+	}, true
+}
+
+// parseSearchDate accepts either YYYY-MM-DD or RFC 3339 timestamps
+func parseSearchDate(value string) (time.Time, error) {
+	if t, err := time.Parse(searchDateLayout, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// searchRangeEnd turns an inclusive "to" value into the exclusive bound
+// SearchParams.To expects: the day after a date, the second after a timestamp
+func searchRangeEnd(value string, parsed time.Time) time.Time {
+	if _, err := time.Parse(searchDateLayout, value); err == nil {
+		return parsed.AddDate(0, 0, 1)
+	}
+	return parsed.Truncate(time.Second).Add(time.Second)
+}
+
+// SearchStories searches historical stories. Remote results from the
+// search API are merged with matching stories already in the database.
+// Supports ?q=, ?from= and ?to= (YYYY-MM-DD or RFC 3339; "to" is
+// inclusive) and ?sort=relevance|date.
+func (h *Handler) SearchStories(c *fiber.Ctx) error {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Missing search query parameter q",
+		})
+	}
+
+	params := SearchParams{
+		Query:       q,
+		Tags:        "story",
+		HitsPerPage: defaultSearchHits,
+	}
+
+	for name, dest := range map[string]*time.Time{"from": &params.From, "to": &params.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseSearchDate(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Invalid %s date %q, expected YYYY-MM-DD or RFC 3339", name, value),
+			})
+		}
+		if name == "to" {
+			parsed = searchRangeEnd(value, parsed)
+		}
+		*dest = parsed
+	}
+
+	byDate := c.Query("sort") == "date"
+	search := h.search.Search
+	if byDate {
+		search = h.search.SearchByDate
+	}
+
+	stories := make([]Story, 0, defaultSearchHits)
+	seen := make(map[int]bool)
+
+	resp, remoteErr := search(params)
+	if remoteErr != nil {
+		log.Printf("[HackerNews Search] Remote search failed, serving local results only: %v", remoteErr)
+	} else {
+		for _, hit := range resp.Hits {
+			story, ok := hit.toStory()
+			if !ok || seen[story.ID] {
+				continue
+			}
+			seen[story.ID] = true
+			stories = append(stories, story)
+		}
+	}
+
+	local, localErr := searchStoredStories(params)
+	if localErr != nil {
+		log.Printf("[HackerNews Search] Local search failed: %v", localErr)
+		if remoteErr != nil {
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": fmt.Sprintf("Search failed: %v", remoteErr),
+			})
+		}
+	}
+	for _, story := range local {
+		if !seen[story.ID] {
+			seen[story.ID] = true
+			stories = append(stories, story)
+		}
+	}
+
+	if byDate {
+		sortStoriesByTime(stories)
+	}
+
+	log.Printf("[HackerNews Search] Returned %d stories for %q", len(stories), q)
+	return c.JSON(stories)
+}
+
+// likeEscaper escapes LIKE wildcards so they match literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// searchStoredStories finds stored stories whose title matches the query
+func searchStoredStories(params SearchParams) ([]Story, error) {
+	db := database.GetDB()
+
+	query := `
+		SELECT id, by, descendants, score, time, title, type, url
+		FROM hackernews_stories
+		WHERE title LIKE ? ESCAPE '\'
+	`
+	args := []interface{}{"%" + likeEscaper.Replace(params.Query) + "%"}
+	if !params.From.IsZero() {
+		query += " AND time >= ?"
+		args = append(args, params.From.Unix())
+	}
+	if !params.To.IsZero() {
+		query += " AND time < ?"
+		args = append(args, params.To.Unix())
+	}
+	query += " ORDER BY score DESC LIMIT ?"
+	args = append(args, defaultSearchHits)
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var stories []Story
+	for rows.Next() {
+		var story Story
+		err := rows.Scan(
+			&story.ID,
+			&story.By,
+			&story.Descendants,
+			&story.Score,
+			&story.Time,
+			&story.Title,
+			&story.Type,
+			&story.URL,
+		)
+		if err != nil {
+			log.Printf("[HackerNews Search] Failed to scan story from database: %v", err)
+			continue
+		}
+		stories = append(stories, story)
+	}
+
+	return stories, rows.Err()
+}
@@ -10,4 +10,41 @@ type Story struct {
 	Title       string `json:"title"`
 	Type        string `json:"type"`
 	URL         string `json:"url"`
+	Rank        int    `json:"rank"`
+}
+
+// Item represents any Hacker News item (story, comment, job, poll)
+type Item struct {
+	ID      int    `json:"id"`
+	By      string `json:"by"`
+	Time    int64  `json:"time"`
+	Title   string `json:"title"`
+	Text    string `json:"text"`
+	Type    string `json:"type"`
+	Parent  int    `json:"parent"`
+	Kids    []int  `json:"kids"`
+	Deleted bool   `json:"deleted"`
+	Dead    bool   `json:"dead"`
+}
+
+// User represents a Hacker News user profile
+type User struct {
+	ID        string `json:"id"`
+	Submitted []int  `json:"submitted"`
+}
+
+// HiringPost is a job posting parsed from a "Who is hiring?" comment
+type HiringPost struct {
+	ID       int      `json:"id"`
+	ThreadID int      `json:"threadId"`
+	By       string   `json:"by"`
+	Time     int64    `json:"time"`
+	Company  string   `json:"company"`
+	Location string   `json:"location"`
+	Remote   bool     `json:"remote"`
+	Onsite   bool     `json:"onsite"`
+	Roles    []string `json:"roles"`
+	Salary   string   `json:"salary"`
+	URL      string   `json:"url"`
+	Text     string   `json:"text"`
 }
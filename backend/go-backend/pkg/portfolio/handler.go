@@ -0,0 +1,422 @@
+package portfolio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+	"go-backend/pkg/tickers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	errLotNotFound     = errors.New("lot not found")
+	errHoldingNotFound = errors.New("holding not found")
+
+	currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+)
+
+// QuoteSource provides the quotes positions are valued at
+type QuoteSource interface {
+	Quotes(symbols []string, base string) ([]tickers.TickerData, error)
+	ValidateSymbol(symbol string) (string, error)
+}
+
+// Handler for portfolio operations
+type Handler struct {
+	quotes QuoteSource
+	// currency totals are reported in unless ?base= is given
+	currency string
+}
+
+// NewHandler creates a portfolio handler valued with quotes from source.
+// PORTFOLIO_CURRENCY sets the default reporting currency (USD).
+func NewHandler(source QuoteSource) *Handler {
+	currency := strings.ToUpper(os.Getenv("PORTFOLIO_CURRENCY"))
+	if !currencyRegex.MatchString(currency) {
+		currency = "USD"
+	}
+	return &Handler{
+		quotes:   source,
+		currency: currency,
+	}
+}
+
+// Initialize creates the portfolio tables if they don't exist
+func (h *Handler) Initialize() error {
+	db := database.GetDB()
+
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS portfolio_lots (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			quantity REAL NOT NULL,
+			cost_basis REAL NOT NULL,  -- Price per unit in the symbol's currency
+			acquired_on TEXT NOT NULL, -- YYYY-MM-DD
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS portfolio_snapshots (
+			date TEXT PRIMARY KEY,
+			currency TEXT NOT NULL,
+			market_value REAL NOT NULL,
+			cost_basis REAL NOT NULL,
+			day_pnl REAL NOT NULL,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+func (h *Handler) RegisterRoutes(app *fiber.App) {
+	// Initialize database tables
+	if err := h.Initialize(); err != nil {
+		log.Fatalf("[Portfolio] Failed to initialize database: %v", err)
+	}
+
+	app.Get("/portfolio", h.GetPortfolio)
+	app.Get("/portfolio/history", h.GetHistory)
+	app.Get("/portfolio/holdings", h.GetHoldings)
+	app.Post("/portfolio/holdings", h.AddLot)
+	app.Delete("/portfolio/holdings/:symbol", h.DeleteHolding)
+	app.Delete("/portfolio/lots/:id", h.DeleteLot)
+}
+
+// AddToJobScheduler records a daily value snapshot when PORTFOLIO_SNAPSHOTS
+// is "true". The job runs hourly and overwrites the current day's row, so
+// the last run of the day keeps the closing value.
+func (h *Handler) AddToJobScheduler(addJob func(string, time.Duration, func() error)) {
+	if os.Getenv("PORTFOLIO_SNAPSHOTS") != "true" {
+		return
+	}
+	addJob("Portfolio Snapshot", time.Hour, h.RecordSnapshot)
+}
+
+func listLots(clause string, args ...interface{}) ([]Lot, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT id, symbol, quantity, cost_basis, acquired_on, created_at
+		FROM portfolio_lots
+		`+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lots := make([]Lot, 0)
+	for rows.Next() {
+		var lot Lot
+		if err := rows.Scan(&lot.ID, &lot.Symbol, &lot.Quantity, &lot.CostBasis, &lot.AcquiredOn, &lot.CreatedAt); err != nil {
+			return nil, err
+		}
+		lots = append(lots, lot)
+	}
+	return lots, rows.Err()
+}
+
+// listHoldings groups every lot by symbol, in the order symbols were first bought
+func listHoldings() ([]Holding, error) {
+	lots, err := listLots(`ORDER BY acquired_on ASC, id ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	holdings := make([]Holding, 0)
+	index := make(map[string]int)
+	for _, lot := range lots {
+		i, ok := index[lot.Symbol]
+		if !ok {
+			i = len(holdings)
+			index[lot.Symbol] = i
+			holdings = append(holdings, Holding{Symbol: lot.Symbol, Lots: make([]Lot, 0)})
+		}
+		holding := &holdings[i]
+		holding.Quantity += lot.Quantity
+		holding.CostBasis += lot.Quantity * lot.CostBasis
+		holding.Lots = append(holding.Lots, lot)
+	}
+	for i := range holdings {
+		if holdings[i].Quantity != 0 {
+			holdings[i].AverageCost = holdings[i].CostBasis / holdings[i].Quantity
+		}
+	}
+
+	return holdings, nil
+}
+
+// computeSummary values every holding in base currency
+func (h *Handler) computeSummary(base string) (*Summary, error) {
+	holdings, err := listHoldings()
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &Summary{
+		Currency:  base,
+		Positions: make([]Position, 0, len(holdings)),
+	}
+	if len(holdings) == 0 {
+		return summary, nil
+	}
+
+	symbols := make([]string, len(holdings))
+	for i, holding := range holdings {
+		symbols[i] = holding.Symbol
+	}
+	quotes, err := h.quotes.Quotes(symbols, base)
+	if err != nil {
+		return nil, err
+	}
+	bySymbol := make(map[string]tickers.TickerData, len(quotes))
+	for _, quote := range quotes {
+		bySymbol[quote.Ticker] = quote
+	}
+
+	var previousValue float64
+	for _, holding := range holdings {
+		quote := bySymbol[holding.Symbol]
+		if quote.Unconverted {
+			// Its prices are in another currency, so it can't be added up
+			summary.Positions = append(summary.Positions, Position{
+				Symbol:      holding.Symbol,
+				Quantity:    holding.Quantity,
+				Stale:       quote.Stale,
+				Unconverted: true,
+			})
+			continue
+		}
+		position, previous := valuePosition(holding, quote)
+		summary.MarketValue += position.MarketValue
+		summary.CostBasis += position.CostBasis
+		summary.DayPnL += position.DayPnL
+		summary.TotalPnL += position.TotalPnL
+		summary.Positions = append(summary.Positions, position)
+		previousValue += previous
+	}
+
+	for i := range summary.Positions {
+		if summary.MarketValue != 0 {
+			summary.Positions[i].Allocation = summary.Positions[i].MarketValue / summary.MarketValue * 100
+		}
+	}
+	summary.DayPnLPercent = percentOf(summary.DayPnL, previousValue)
+	summary.TotalPnLPercent = percentOf(summary.TotalPnL, summary.CostBasis)
+
+	return summary, nil
+}
+
+// valuePosition values a holding at quote, whose prices are already in the
+// reporting currency. Costs are converted at the same, current FX rate, not
+// the rate of each lot's purchase date, so total P&L leaves out currency
+// moves since purchase. It also returns the value the position had at the
+// previous close, counting lots bought today at their cost.
+func valuePosition(holding Holding, quote tickers.TickerData) (Position, float64) {
+	rate := 1.0
+	if quote.FXRate != nil {
+		rate = *quote.FXRate
+	}
+
+	position := Position{
+		Symbol:    holding.Symbol,
+		Quantity:  holding.Quantity,
+		CostBasis: holding.CostBasis * rate,
+		Stale:     quote.Stale,
+	}
+	if quote.TodaysPrice == nil {
+		// Without a quote the position is carried at cost
+		position.MarketValue = position.CostBasis
+		return position, position.CostBasis
+	}
+
+	price := *quote.TodaysPrice
+	position.Price = &price
+	position.MarketValue = holding.Quantity * price
+	position.TotalPnL = position.MarketValue - position.CostBasis
+	position.TotalPnLPercent = percentOf(position.TotalPnL, position.CostBasis)
+
+	previousClose := price
+	if quote.DayChange != nil {
+		previousClose = price / (1 + *quote.DayChange/100)
+	}
+	today := ""
+	if quote.AsOf != nil {
+		today = quote.AsOf.Format("2006-01-02")
+	}
+
+	var previousValue float64
+	for _, lot := range holding.Lots {
+		if lot.AcquiredOn == today {
+			previousValue += lot.Quantity * lot.CostBasis * rate
+		} else {
+			previousValue += lot.Quantity * previousClose
+		}
+	}
+	position.DayPnL = position.MarketValue - previousValue
+	position.DayPnLPercent = percentOf(position.DayPnL, previousValue)
+
+	return position, previousValue
+}
+
+func percentOf(value, total float64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	percent := value / total * 100
+	return &percent
+}
+
+// baseParam reads ?base=, defaulting to the handler's currency
+func (h *Handler) baseParam(c *fiber.Ctx) (string, error) {
+	base := strings.ToUpper(c.Query("base", h.currency))
+	if !currencyRegex.MatchString(base) {
+		return "", fmt.Errorf("Invalid base currency %q, expected an ISO 4217 code such as EUR", base)
+	}
+	return base, nil
+}
+
+func databaseError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errLotNotFound), errors.Is(err, errHoldingNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("[Portfolio] Database error: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Portfolio database error: %v", err),
+	})
+}
+
+// GetPortfolio values the portfolio, e.g. /portfolio?base=EUR
+func (h *Handler) GetPortfolio(c *fiber.Ctx) error {
+	base, err := h.baseParam(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	summary, err := h.computeSummary(base)
+	if err != nil {
+		log.Printf("[Portfolio] Failed to value portfolio: %v", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to value portfolio: %v", err),
+		})
+	}
+	return c.JSON(summary)
+}
+
+// GetHoldings lists holdings with their lots
+func (h *Handler) GetHoldings(c *fiber.Ctx) error {
+	holdings, err := listHoldings()
+	if err != nil {
+		return databaseError(c, err)
+	}
+	return c.JSON(holdings)
+}
+
+// AddLot records a purchase, e.g.
+// {"symbol": "VTI", "quantity": 10, "costBasis": 245.10, "acquiredOn": "2026-03-02"}
+func (h *Handler) AddLot(c *fiber.Ctx) error {
+	var body Lot
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	if body.Quantity <= 0 || math.IsInf(body.Quantity, 0) || math.IsNaN(body.Quantity) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Quantity must be a positive number"})
+	}
+	if body.CostBasis < 0 || math.IsInf(body.CostBasis, 0) || math.IsNaN(body.CostBasis) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cost basis must be zero or a positive number"})
+	}
+	if body.AcquiredOn == "" {
+		body.AcquiredOn = time.Now().In(marketLocation).Format("2006-01-02")
+	} else if _, err := time.Parse("2006-01-02", body.AcquiredOn); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid acquiredOn %q, expected YYYY-MM-DD", body.AcquiredOn),
+		})
+	}
+
+	symbol, err := h.quotes.ValidateSymbol(body.Symbol)
+	if errors.Is(err, tickers.ErrInvalidSymbol) || errors.Is(err, tickers.ErrSymbolNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Could not validate symbol: %v", err),
+		})
+	}
+
+	result, err := database.GetDB().Exec(`
+		INSERT INTO portfolio_lots (symbol, quantity, cost_basis, acquired_on)
+		VALUES (?, ?, ?, ?)
+	`, symbol, body.Quantity, body.CostBasis, body.AcquiredOn)
+	if err != nil {
+		return databaseError(c, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return databaseError(c, err)
+	}
+
+	lots, err := listLots(`WHERE id = ?`, id)
+	if err != nil {
+		return databaseError(c, err)
+	}
+	if len(lots) == 0 {
+		return databaseError(c, errLotNotFound)
+	}
+
+	log.Printf("[Portfolio] Added lot %d: %v %s at %v", id, body.Quantity, symbol, body.CostBasis)
+	return c.Status(fiber.StatusCreated).JSON(lots[0])
+}
+
+// DeleteHolding removes every lot of a symbol
+func (h *Handler) DeleteHolding(c *fiber.Ctx) error {
+	symbol := c.Params("symbol")
+	if unescaped, err := url.PathUnescape(symbol); err == nil {
+		symbol = unescaped
+	}
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+
+	result, err := database.GetDB().Exec(`DELETE FROM portfolio_lots WHERE symbol = ?`, symbol)
+	if err != nil {
+		return databaseError(c, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return databaseError(c, fmt.Errorf("%w: %s", errHoldingNotFound, symbol))
+	}
+
+	log.Printf("[Portfolio] Deleted holding %s", symbol)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// DeleteLot removes a single lot, e.g. after a partial sale was entered wrong
+func (h *Handler) DeleteLot(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return databaseError(c, errLotNotFound)
+	}
+
+	result, err := database.GetDB().Exec(`DELETE FROM portfolio_lots WHERE id = ?`, id)
+	if err != nil {
+		return databaseError(c, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return databaseError(c, errLotNotFound)
+	}
+
+	log.Printf("[Portfolio] Deleted lot %d", id)
+	return c.SendStatus(fiber.StatusNoContent)
+}
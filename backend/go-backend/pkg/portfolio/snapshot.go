@@ -0,0 +1,76 @@
+package portfolio
+
+import (
+	"log"
+	"time"
+
+	"go-backend/pkg/database"
+	"go-backend/pkg/tickers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Snapshots and default purchase dates follow the US trading day
+var marketLocation = tickers.MustLoadLocation("America/New_York")
+
+// RecordSnapshot stores today's portfolio value, replacing an earlier
+// snapshot of the same day
+func (h *Handler) RecordSnapshot() error {
+	summary, err := h.computeSummary(h.currency)
+	if err != nil {
+		return err
+	}
+	if len(summary.Positions) == 0 {
+		return nil
+	}
+
+	date := time.Now().In(marketLocation).Format("2006-01-02")
+	_, err = database.GetDB().Exec(`
+		INSERT OR REPLACE INTO portfolio_snapshots (date, currency, market_value, cost_basis, day_pnl)
+		VALUES (?, ?, ?, ?, ?)
+	`, date, summary.Currency, summary.MarketValue, summary.CostBasis, summary.DayPnL)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("[Portfolio] Recorded snapshot for %s: %.2f %s", date, summary.MarketValue, summary.Currency)
+	return nil
+}
+
+// GetHistory returns recorded snapshots, oldest first, e.g.
+// /portfolio/history?from=2026-01-01
+func (h *Handler) GetHistory(c *fiber.Ctx) error {
+	from := c.Query("from", "0000-00-00")
+	if from != "0000-00-00" {
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid from date, expected YYYY-MM-DD",
+			})
+		}
+	}
+
+	rows, err := database.GetDB().Query(`
+		SELECT date, currency, market_value, cost_basis, day_pnl
+		FROM portfolio_snapshots
+		WHERE date >= ?
+		ORDER BY date ASC
+	`, from)
+	if err != nil {
+		return databaseError(c, err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]Snapshot, 0)
+	for rows.Next() {
+		var s Snapshot
+		if err := rows.Scan(&s.Date, &s.Currency, &s.MarketValue, &s.CostBasis, &s.DayPnL); err != nil {
+			return databaseError(c, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return databaseError(c, err)
+	}
+
+	return c.JSON(snapshots)
+}
@@ -0,0 +1,62 @@
+package portfolio
+
+import "time"
+
+// Lot is one purchase of a symbol. CostBasis is the price paid per unit,
+// in the symbol's trading currency.
+type Lot struct {
+	ID         int64     `json:"id"`
+	Symbol     string    `json:"symbol"`
+	Quantity   float64   `json:"quantity"`
+	CostBasis  float64   `json:"costBasis"`
+	AcquiredOn string    `json:"acquiredOn"` // YYYY-MM-DD
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// Holding is every lot of one symbol
+type Holding struct {
+	Symbol      string  `json:"symbol"`
+	Quantity    float64 `json:"quantity"`
+	CostBasis   float64 `json:"costBasis"` // Total paid across lots
+	AverageCost float64 `json:"averageCost"`
+	Lots        []Lot   `json:"lots"`
+}
+
+// Position is a holding valued at the latest quote
+type Position struct {
+	Symbol          string   `json:"symbol"`
+	Quantity        float64  `json:"quantity"`
+	Price           *float64 `json:"price"`
+	MarketValue     float64  `json:"marketValue"`
+	CostBasis       float64  `json:"costBasis"` // Converted at today's FX rate
+	DayPnL          float64  `json:"dayPnl"`
+	DayPnLPercent   *float64 `json:"dayPnlPercent"`
+	TotalPnL        float64  `json:"totalPnl"`
+	TotalPnLPercent *float64 `json:"totalPnlPercent"`
+	Allocation      float64  `json:"allocation"` // Percent of portfolio market value
+	Stale           bool     `json:"stale"`
+	// Unconverted is set when no exchange rate into the portfolio currency
+	// was available. The position is then left out of the totals.
+	Unconverted bool `json:"unconverted,omitempty"`
+}
+
+// Summary is returned by GET /portfolio
+type Summary struct {
+	Currency        string     `json:"currency"`
+	MarketValue     float64    `json:"marketValue"`
+	CostBasis       float64    `json:"costBasis"`
+	DayPnL          float64    `json:"dayPnl"`
+	DayPnLPercent   *float64   `json:"dayPnlPercent"`
+	TotalPnL        float64    `json:"totalPnl"`
+	TotalPnLPercent *float64   `json:"totalPnlPercent"`
+	Positions       []Position `json:"positions"`
+}
+
+// Snapshot is the portfolio value recorded for one day
+type Snapshot struct {
+	Date        string  `json:"date"`
+	Currency    string  `json:"currency"`
+	MarketValue float64 `json:"marketValue"`
+	CostBasis   float64 `json:"costBasis"`
+	DayPnL      float64 `json:"dayPnl"`
+}
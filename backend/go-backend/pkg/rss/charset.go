@@ -0,0 +1,125 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"mime"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/unicode"
+	"golang.org/x/text/transform"
+)
+
+// xmlDeclEncoding matches the encoding of an XML declaration such as
+// <?xml version="1.0" encoding="ISO-8859-1"?>
+var xmlDeclEncoding = regexp.MustCompile(`^(\s*<\?xml[^>]*?\sencoding\s*=\s*)["']([^"']*)["']`)
+
+// fallbackCharset is assumed for documents that declare nothing and are not
+// valid UTF-8. Windows-1252 is a superset of ISO-8859-1 and by far the most
+// common mislabelled encoding.
+const fallbackCharset = "windows-1252"
+
+// feedAutoClose lists the HTML void elements the lenient decoder closes on
+// its own. <link> is left out as RSS and Atom use it with content.
+var feedAutoClose = func() []string {
+	var names []string
+	for _, name := range xml.HTMLAutoClose {
+		if name != "link" {
+			names = append(names, name)
+		}
+	}
+	return names
+}()
+
+// toUTF8 transcodes a feed to UTF-8. The encoding comes from a byte order
+// mark, then the Content-Type charset, then the XML declaration. A charset
+// claiming UTF-8 for a body that isn't is ignored, as is common with
+// misconfigured servers. The XML declaration is rewritten to say UTF-8.
+func toUTF8(body []byte, contentType string) ([]byte, error) {
+	if bytes.HasPrefix(body, []byte("\xef\xbb\xbf")) ||
+		bytes.HasPrefix(body, []byte("\xfe\xff")) || bytes.HasPrefix(body, []byte("\xff\xfe")) {
+		decoded, _, err := transform.Bytes(unicode.BOMOverride(encoding.Nop.NewDecoder()), body)
+		if err != nil {
+			return nil, err
+		}
+		return declareUTF8(decoded), nil
+	}
+
+	var labels []string
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		labels = append(labels, params["charset"])
+	}
+	if match := xmlDeclEncoding.FindSubmatch(body); match != nil {
+		labels = append(labels, string(match[2]))
+	}
+
+	valid := utf8.Valid(body)
+	for _, label := range labels {
+		enc, name := charset.Lookup(strings.TrimSpace(label))
+		if enc == nil || (name == "utf-8" && !valid) {
+			continue
+		}
+		if name == "utf-8" {
+			return declareUTF8(body), nil
+		}
+		return decodeCharset(body, enc)
+	}
+
+	if valid {
+		return declareUTF8(body), nil
+	}
+	enc, _ := charset.Lookup(fallbackCharset)
+	return decodeCharset(body, enc)
+}
+
+func decodeCharset(body []byte, enc encoding.Encoding) ([]byte, error) {
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, err
+	}
+	return declareUTF8(decoded), nil
+}
+
+// declareUTF8 makes the XML declaration, if any, match the transcoded body
+func declareUTF8(body []byte) []byte {
+	return xmlDeclEncoding.ReplaceAll(body, []byte(`${1}"UTF-8"`))
+}
+
+// newXMLDecoder returns a decoder that knows HTML entities such as &nbsp;
+// and any charset an XML declaration names. A non-strict decoder also
+// accepts stray ampersands, unquoted attributes and unclosed elements.
+func newXMLDecoder(body []byte, strict bool) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Entity = xml.HTMLEntity
+	decoder.Strict = strict
+	if !strict {
+		decoder.AutoClose = feedAutoClose
+	}
+	return decoder
+}
+
+// unmarshalXML decodes a feed document into v. Well-formed documents are
+// decoded strictly; a document with syntax errors gets a second, lenient
+// attempt before the original error is returned.
+func unmarshalXML(body []byte, v interface{}) error {
+	err := newXMLDecoder(body, true).Decode(v)
+	var syntaxErr *xml.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+
+	// Drop whatever the strict attempt decoded before it failed
+	target := reflect.ValueOf(v).Elem()
+	target.Set(reflect.Zero(target.Type()))
+	if newXMLDecoder(body, false).Decode(v) != nil {
+		return err
+	}
+	return nil
+}
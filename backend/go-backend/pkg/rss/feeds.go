@@ -0,0 +1,444 @@
+package rss
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Defaults and bounds for per-feed settings
+const (
+	defaultItemLimit      = 5
+	maxItemLimit          = 100
+	defaultRefreshMinutes = 30
+	minRefreshMinutes     = 5
+	maxRefreshMinutes     = 24 * 60
+
+	// maxFreshFor caps how long a server's max-age or <ttl> can postpone
+	// the next fetch
+	maxFreshFor = 24 * time.Hour
+)
+
+var (
+	errFeedNotFound = errors.New("feed not found")
+	errFeedExists   = errors.New("a feed with this name or URL already exists")
+)
+
+// initFeedTables creates the rss_feeds table and seeds it from RSSFeedList
+// on first run
+func initFeedTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS rss_feeds (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			url TEXT NOT NULL UNIQUE,
+			category TEXT NOT NULL DEFAULT '',
+			item_limit INTEGER NOT NULL DEFAULT 5,
+			enabled BOOLEAN NOT NULL DEFAULT 1,
+			refresh_minutes INTEGER NOT NULL DEFAULT 30,
+			last_fetched_at TIMESTAMP,
+			etag TEXT NOT NULL DEFAULT '',           -- Sent back as If-None-Match
+			last_modified TEXT NOT NULL DEFAULT '',  -- Sent back as If-Modified-Since
+			fresh_until TIMESTAMP,  -- Not refetched before this, from max-age or <ttl>
+			last_attempt_at TIMESTAMP,  -- last_fetched_at is the last successful fetch
+			consecutive_failures INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			last_status INTEGER NOT NULL DEFAULT 0,
+			item_count INTEGER NOT NULL DEFAULT 0,
+			auto_disabled BOOLEAN NOT NULL DEFAULT 0,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Databases created before conditional fetching need these columns
+	if err := database.EnsureColumn("rss_feeds", "etag", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := database.EnsureColumn("rss_feeds", "last_modified", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := database.EnsureColumn("rss_feeds", "fresh_until", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err := initHealthColumns(); err != nil {
+		return err
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM rss_feeds`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	// Seed in name order so feed ids are stable across installs
+	names := make([]string, 0, len(RSSFeedList))
+	for name := range RSSFeedList {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, err := db.Exec(`
+			INSERT INTO rss_feeds (name, url, category) VALUES (?, ?, 'tech')
+		`, name, RSSFeedList[name])
+		if err != nil {
+			return err
+		}
+	}
+	log.Printf("[RSS DB] Seeded %d feeds", len(names))
+	return nil
+}
+
+func queryFeeds(clause string, args ...interface{}) ([]RSSFeed, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT id, name, url, category, item_limit, enabled, refresh_minutes, last_fetched_at,
+			etag, last_modified, fresh_until, last_attempt_at, consecutive_failures, last_error,
+			last_status, item_count, auto_disabled, created_at
+		FROM rss_feeds
+		`+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	feeds := make([]RSSFeed, 0)
+	for rows.Next() {
+		var feed RSSFeed
+		var lastFetchedAt, freshUntil, lastAttemptAt sql.NullTime
+		err := rows.Scan(&feed.ID, &feed.Name, &feed.URL, &feed.Category, &feed.ItemLimit,
+			&feed.Enabled, &feed.RefreshMinutes, &lastFetchedAt,
+			&feed.validators.ETag, &feed.validators.LastModified, &freshUntil, &lastAttemptAt,
+			&feed.Health.ConsecutiveFailures, &feed.Health.LastError, &feed.Health.LastStatus,
+			&feed.Health.ItemCount, &feed.Health.AutoDisabled, &feed.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		if lastFetchedAt.Valid {
+			feed.LastFetchedAt = &lastFetchedAt.Time
+			feed.Health.LastSuccessAt = &lastFetchedAt.Time
+		}
+		if lastAttemptAt.Valid {
+			feed.Health.LastAttemptAt = &lastAttemptAt.Time
+		}
+		if freshUntil.Valid {
+			feed.FreshUntil = &freshUntil.Time
+		}
+		feeds = append(feeds, feed)
+	}
+	return feeds, rows.Err()
+}
+
+// listFeeds returns feeds in id order, so fetching is deterministic
+func listFeeds(enabledOnly bool) ([]RSSFeed, error) {
+	if enabledOnly {
+		return queryFeeds(`WHERE enabled = 1 ORDER BY id ASC`)
+	}
+	return queryFeeds(`ORDER BY id ASC`)
+}
+
+func getFeed(id int64) (*RSSFeed, error) {
+	feeds, err := queryFeeds(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(feeds) == 0 {
+		return nil, errFeedNotFound
+	}
+	return &feeds[0], nil
+}
+
+// due reports whether the feed's refresh interval has passed since the last
+// attempt and the server's freshness lifetime, if any, has run out. Failing
+// feeds are retried at their refresh interval too.
+func (f RSSFeed) due(now time.Time) bool {
+	if f.FreshUntil != nil && now.Before(*f.FreshUntil) {
+		return false
+	}
+	last := f.Health.LastAttemptAt
+	if last == nil {
+		last = f.LastFetchedAt
+	}
+	if last == nil {
+		return true
+	}
+	return now.Sub(*last) >= time.Duration(f.RefreshMinutes)*time.Minute
+}
+
+// recordFetch stores a successful fetch along with the validators and
+// freshness lifetime of the response, and clears the feed's failures
+func recordFetch(id int64, at time.Time, resp *feedResponse) error {
+	var freshUntil *time.Time
+	if resp.FreshFor > 0 {
+		until := at.Add(min(resp.FreshFor, maxFreshFor)).UTC()
+		freshUntil = &until
+	}
+	// A 304 carries no entries, so the previous count still holds
+	_, err := database.GetDB().Exec(`
+		UPDATE rss_feeds
+		SET last_fetched_at = ?, last_attempt_at = ?, etag = ?, last_modified = ?, fresh_until = ?,
+			consecutive_failures = 0, last_error = '', last_status = ?,
+			item_count = CASE WHEN ? THEN item_count ELSE ? END
+		WHERE id = ?
+	`, at.UTC(), at.UTC(), resp.Validators.ETag, resp.Validators.LastModified, freshUntil,
+		resp.Status, resp.NotModified, len(resp.Entries), id)
+	return err
+}
+
+// newFeed returns a feed with the default settings
+func newFeed() RSSFeed {
+	return RSSFeed{
+		ItemLimit:      defaultItemLimit,
+		Enabled:        true,
+		RefreshMinutes: defaultRefreshMinutes,
+	}
+}
+
+// isUniqueViolation matches SQLite's constraint error for duplicate names/URLs
+func isUniqueViolation(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// feedInput is the body of POST and PUT /news/feeds. Fields left out of a
+// PUT keep their current value.
+type feedInput struct {
+	Name           *string `json:"name"`
+	URL            *string `json:"url"`
+	Category       *string `json:"category"`
+	ItemLimit      *int    `json:"itemLimit"`
+	Enabled        *bool   `json:"enabled"`
+	RefreshMinutes *int    `json:"refreshMinutes"`
+}
+
+// validateFeedName checks the length limit of a feed name
+func validateFeedName(name string) error {
+	if name == "" || len(name) > 100 {
+		return fmt.Errorf("Feed name must be 1-100 characters")
+	}
+	return nil
+}
+
+// apply copies the given fields onto feed and validates the result
+func (in feedInput) apply(feed *RSSFeed) error {
+	if in.Name != nil {
+		feed.Name = strings.TrimSpace(*in.Name)
+	}
+	if in.URL != nil {
+		feed.URL = strings.TrimSpace(*in.URL)
+	}
+	if in.Category != nil {
+		feed.Category = strings.ToLower(strings.TrimSpace(*in.Category))
+	}
+	if in.ItemLimit != nil {
+		feed.ItemLimit = *in.ItemLimit
+	}
+	if in.Enabled != nil {
+		feed.Enabled = *in.Enabled
+	}
+	if in.RefreshMinutes != nil {
+		feed.RefreshMinutes = *in.RefreshMinutes
+	}
+
+	if err := validateFeedName(feed.Name); err != nil {
+		return err
+	}
+	parsed, err := url.Parse(feed.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("Invalid feed URL %q, expected an http(s) URL", feed.URL)
+	}
+	if feed.ItemLimit < 1 || feed.ItemLimit > maxItemLimit {
+		return fmt.Errorf("itemLimit must be between 1 and %d", maxItemLimit)
+	}
+	if feed.RefreshMinutes < minRefreshMinutes || feed.RefreshMinutes > maxRefreshMinutes {
+		return fmt.Errorf("refreshMinutes must be between %d and %d", minRefreshMinutes, maxRefreshMinutes)
+	}
+	return nil
+}
+
+// validateFeedURL fetches and parses the feed so broken URLs are rejected
+// before they are saved
+func (h *Handler) validateFeedURL(feedURL string) error {
+	if _, err := h.FetchRSSFeed(feedURL); err != nil {
+		return fmt.Errorf("Feed %s could not be fetched and parsed: %v", feedURL, err)
+	}
+	return nil
+}
+
+func feedError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errFeedNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, errFeedExists), isUniqueViolation(err):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": errFeedExists.Error()})
+	}
+	log.Printf("[RSS DB] Feed database error: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Feed database error: %v", err),
+	})
+}
+
+func feedIDParam(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, errFeedNotFound
+	}
+	return id, nil
+}
+
+// GetFeeds lists every feed subscription
+func (h *Handler) GetFeeds(c *fiber.Ctx) error {
+	feeds, err := listFeeds(false)
+	if err != nil {
+		return feedError(c, err)
+	}
+	return c.JSON(feeds)
+}
+
+// GetFeed returns one feed subscription
+func (h *Handler) GetFeed(c *fiber.Ctx) error {
+	id, err := feedIDParam(c)
+	if err != nil {
+		return feedError(c, err)
+	}
+	feed, err := getFeed(id)
+	if err != nil {
+		return feedError(c, err)
+	}
+	return c.JSON(feed)
+}
+
+// CreateFeed subscribes to a feed, e.g.
+// {"name": "LWN", "url": "https://lwn.net/headlines/rss", "category": "linux"}
+func (h *Handler) CreateFeed(c *fiber.Ctx) error {
+	var body feedInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	feed := newFeed()
+	if err := body.apply(&feed); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err := h.validateFeedURL(feed.URL); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	created, err := insertFeed(feed)
+	if err != nil {
+		return feedError(c, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// insertFeed saves a validated feed and returns it as stored
+func insertFeed(feed RSSFeed) (*RSSFeed, error) {
+	result, err := database.GetDB().Exec(`
+		INSERT INTO rss_feeds (name, url, category, item_limit, enabled, refresh_minutes)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, feed.Name, feed.URL, feed.Category, feed.ItemLimit, feed.Enabled, feed.RefreshMinutes)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := getFeed(id)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("[RSS] Added feed %q (%s)", created.Name, created.URL)
+	return created, nil
+}
+
+// UpdateFeed changes the given fields of a feed. A changed URL is
+// validated like a new feed.
+func (h *Handler) UpdateFeed(c *fiber.Ctx) error {
+	id, err := feedIDParam(c)
+	if err != nil {
+		return feedError(c, err)
+	}
+	feed, err := getFeed(id)
+	if err != nil {
+		return feedError(c, err)
+	}
+
+	var body feedInput
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	previousURL, previousLimit, wasEnabled := feed.URL, feed.ItemLimit, feed.Enabled
+	if err := body.apply(feed); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if feed.URL != previousURL {
+		if err := h.validateFeedURL(feed.URL); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+	}
+
+	// Validators belong to the old URL, and a new item limit needs the full
+	// feed rather than a 304. Re-enabling a feed gives it a fresh set of
+	// tries before it is disabled again.
+	refetch := feed.URL != previousURL || feed.ItemLimit != previousLimit
+	reenabled := feed.Enabled && !wasEnabled
+	_, err = database.GetDB().Exec(`
+		UPDATE rss_feeds
+		SET name = ?, url = ?, category = ?, item_limit = ?, enabled = ?, refresh_minutes = ?,
+			etag = CASE WHEN ? THEN '' ELSE etag END,
+			last_modified = CASE WHEN ? THEN '' ELSE last_modified END,
+			fresh_until = CASE WHEN ? THEN NULL ELSE fresh_until END,
+			consecutive_failures = CASE WHEN ? THEN 0 ELSE consecutive_failures END,
+			auto_disabled = CASE WHEN ? THEN 0 ELSE auto_disabled END
+		WHERE id = ?
+	`, feed.Name, feed.URL, feed.Category, feed.ItemLimit, feed.Enabled, feed.RefreshMinutes,
+		refetch, refetch, refetch, reenabled, reenabled, id)
+	if err != nil {
+		return feedError(c, err)
+	}
+
+	updated, err := getFeed(id)
+	if err != nil {
+		return feedError(c, err)
+	}
+	log.Printf("[RSS] Updated feed %q", updated.Name)
+	return c.JSON(updated)
+}
+
+// DeleteFeed unsubscribes from a feed. Stored news items are kept.
+func (h *Handler) DeleteFeed(c *fiber.Ctx) error {
+	id, err := feedIDParam(c)
+	if err != nil {
+		return feedError(c, err)
+	}
+
+	result, err := database.GetDB().Exec(`DELETE FROM rss_feeds WHERE id = ?`, id)
+	if err != nil {
+		return feedError(c, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return feedError(c, errFeedNotFound)
+	}
+
+	log.Printf("[RSS] Deleted feed %d", id)
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -1,41 +1,25 @@
 package rss
 
 import (
-	"encoding/xml"
+	"database/sql"
 	"fmt"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cache"
 	"go-backend/pkg/database"
 	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 )
 
 // Handler for RSS feed operations
 type Handler struct {
-	client *http.Client
-}
-
-// XML structures for RSS parsing
-type RSS struct {
-	XMLName xml.Name `xml:"rss"`
-	Channel Channel  `xml:"channel"`
-}
-
-type Channel struct {
-	Title       string     `xml:"title"`
-	Description string     `xml:"description"`
-	Link        string     `xml:"link"`
-	Items       []RSSEntry `xml:"item"`
-}
-
-type RSSEntry struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	client       *http.Client
+	fetchWorkers int
+	maxFailures  int
 }
 
 // NewHandler creates a new RSS handler
@@ -44,6 +28,8 @@ func NewHandler() *Handler {
 		client: &http.Client{
 			Timeout: 15 * time.Second,
 		},
+		fetchWorkers: positiveIntFromEnv("RSS_FETCH_CONCURRENCY", defaultFetchWorkers),
+		maxFailures:  positiveIntFromEnv("RSS_MAX_FAILURES", defaultMaxFailures),
 	}
 }
 
@@ -58,6 +44,9 @@ func (h *Handler) Initialize() error {
 			source TEXT NOT NULL,
 			title TEXT NOT NULL,
 			link TEXT NOT NULL UNIQUE,
+			published_at TIMESTAMP,  -- From the feed, NULL when missing or unparseable
+			summary TEXT NOT NULL DEFAULT '',
+			author TEXT NOT NULL DEFAULT '',
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
@@ -65,11 +54,51 @@ func (h *Handler) Initialize() error {
 		return err
 	}
 
-	return nil
+	// Databases created before these columns existed need them added
+	if err = database.EnsureColumn("rss_news", "published_at", "TIMESTAMP"); err != nil {
+		return err
+	}
+	if err = database.EnsureColumn("rss_news", "summary", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err = database.EnsureColumn("rss_news", "author", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+
+	return initFeedTables(db)
+}
+
+// feedValidators are the cache validators a server sent with a feed
+type feedValidators struct {
+	ETag         string
+	LastModified string
+}
+
+// feedResponse is the result of a conditional feed fetch
+type feedResponse struct {
+	Entries []FeedEntry
+	// NotModified is set when the server answered 304 and Entries is empty
+	NotModified bool
+	Status      int
+	Validators  feedValidators
+	// FreshFor is how long the server (Cache-Control max-age) or the feed
+	// (<ttl>) asks readers to wait before fetching again
+	FreshFor time.Duration
+}
+
+// FetchRSSFeed fetches a feed from a given URL and returns its entries.
+// RSS 2.0, RSS 1.0 (RDF), Atom and JSON Feed are detected automatically.
+func (h *Handler) FetchRSSFeed(url string) ([]FeedEntry, error) {
+	resp, err := h.fetchFeed(url, feedValidators{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entries, nil
 }
 
-// FetchRSSFeed fetches and parses an RSS feed from a given URL
-func (h *Handler) FetchRSSFeed(url string) ([]RSSEntry, error) {
+// fetchFeed fetches a feed, sending the validators of the previous response
+// as If-None-Match and If-Modified-Since so unchanged feeds cost a 304
+func (h *Handler) fetchFeed(url string, validators feedValidators) (*feedResponse, error) {
 	log.Printf("[RSS] Fetching feed from %s", url)
 
 	req, err := http.NewRequest("GET", url, nil)
@@ -80,7 +109,13 @@ func (h *Handler) FetchRSSFeed(url string) ([]RSSEntry, error) {
 
 	// Set user agent to avoid being blocked
 	req.Header.Set("User-Agent", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-	req.Header.Set("Accept", "application/rss+xml, application/xml, text/xml, */*")
+	req.Header.Set("Accept", "application/rss+xml, application/atom+xml, application/feed+json, application/xml, text/xml, */*")
+	if validators.ETag != "" {
+		req.Header.Set("If-None-Match", validators.ETag)
+	}
+	if validators.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validators.LastModified)
+	}
 
 	resp, err := h.client.Do(req)
 	if err != nil {
@@ -89,30 +124,73 @@ func (h *Handler) FetchRSSFeed(url string) ([]RSSEntry, error) {
 	}
 	defer resp.Body.Close()
 
+	result := &feedResponse{
+		Validators: feedValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+		Status:   resp.StatusCode,
+		FreshFor: cacheMaxAge(resp.Header.Get("Cache-Control")),
+	}
+
+	if resp.StatusCode == http.StatusNotModified {
+		// A 304 may omit validators that are still current
+		if result.Validators.ETag == "" {
+			result.Validators.ETag = validators.ETag
+		}
+		if result.Validators.LastModified == "" {
+			result.Validators.LastModified = validators.LastModified
+		}
+		result.NotModified = true
+		log.Printf("[RSS] Feed %s not modified", url)
+		return result, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
 		log.Printf("[RSS] Request to %s returned non-OK status %d", url, resp.StatusCode)
-		return nil, fmt.Errorf("request to %s returned status %s", url, resp.Status)
+		return nil, &httpStatusError{URL: url, Code: resp.StatusCode, Status: resp.Status}
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("[RSS] Failed to read response body: %v", err)
-		return nil, err
+		return nil, &httpStatusError{URL: url, Code: resp.StatusCode, Status: resp.Status, Err: err}
 	}
 
-	var rss RSS
-	err = xml.Unmarshal(bodyBytes, &rss)
+	feed, err := ParseFeedWithContentType(bodyBytes, resp.Header.Get("Content-Type"))
 	if err != nil {
-		// Some feeds might have different formats, try alternative parsing here if needed
-		log.Printf("[RSS] Failed to parse XML from %s: %v", url, err)
-		return nil, err
+		log.Printf("[RSS] Failed to parse feed from %s: %v", url, err)
+		return nil, &httpStatusError{URL: url, Code: resp.StatusCode, Status: resp.Status, Err: err}
 	}
+	result.Entries = feed.Entries
+	if feed.TTL > result.FreshFor {
+		result.FreshFor = feed.TTL
+	}
+
+	log.Printf("[RSS] Parsed %d entries from %s feed %s", len(feed.Entries), feed.Format, url)
+	return result, nil
+}
 
-	return rss.Channel.Items, nil
+// cacheMaxAge reads max-age from a Cache-Control header. no-cache and
+// no-store mean the response may not be reused, so they count as zero.
+func cacheMaxAge(header string) time.Duration {
+	var maxAge time.Duration
+	for _, directive := range strings.Split(header, ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-cache", "no-store":
+			return 0
+		case "max-age":
+			if seconds, err := strconv.Atoi(strings.Trim(value, `"`)); err == nil && seconds > 0 {
+				maxAge = time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return maxAge
 }
 
 // StoreRSSItems stores RSS items in the database
-func (h *Handler) StoreRSSItems(source string, items []RSSEntry) (int, error) {
+func (h *Handler) StoreRSSItems(source string, items []FeedEntry) (int, error) {
 	db := database.GetDB()
 
 	stored := 0
@@ -121,14 +199,22 @@ func (h *Handler) StoreRSSItems(source string, items []RSSEntry) (int, error) {
 			continue // Skip items without links
 		}
 
+		// Known links only get details that older versions did not store
 		_, err := db.Exec(`
-			INSERT OR IGNORE INTO rss_news
-			(source, title, link)
-			VALUES (?, ?, ?)
+			INSERT INTO rss_news
+			(source, title, link, published_at, summary, author)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(link) DO UPDATE SET
+				published_at = COALESCE(rss_news.published_at, excluded.published_at),
+				summary = CASE WHEN rss_news.summary = '' THEN excluded.summary ELSE rss_news.summary END,
+				author = CASE WHEN rss_news.author = '' THEN excluded.author ELSE rss_news.author END
 		`,
 			source,
 			strings.TrimSpace(item.Title),
 			strings.TrimSpace(item.Link),
+			item.Published,
+			plainSummary(firstNonEmpty(item.Summary, item.Content)),
+			strings.TrimSpace(item.Author),
 		)
 		if err != nil {
 			log.Printf("[RSS DB] Failed to store RSS item '%s' in database: %v", item.Title, err)
@@ -141,99 +227,256 @@ func (h *Handler) StoreRSSItems(source string, items []RSSEntry) (int, error) {
 	return stored, nil
 }
 
-// FetchAllFeeds fetches all RSS feeds and updates the database
+// FetchAllFeeds fetches all enabled RSS feeds and updates the database
 func (h *Handler) FetchAllFeeds() ([]RSSItem, error) {
+	feeds, err := listFeeds(true)
+	if err != nil {
+		return nil, err
+	}
+	return h.fetchFeeds(feeds), nil
+}
+
+// FetchDueFeeds fetches the enabled feeds whose refresh interval has passed
+func (h *Handler) FetchDueFeeds() error {
+	feeds, err := listFeeds(true)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var due []RSSFeed
+	for _, feed := range feeds {
+		if feed.due(now) {
+			due = append(due, feed)
+		}
+	}
+	if len(due) > 0 {
+		h.fetchFeeds(due)
+	}
+	return nil
+}
+
+// fetchFeeds fetches feeds concurrently, then records each feed's health
+// and stores up to its item limit, in feed order
+func (h *Handler) fetchFeeds(feeds []RSSFeed) []RSSItem {
 	var allNews []RSSItem
 
-	for source, url := range RSSFeedList {
-		entries, err := h.FetchRSSFeed(url)
+	results := h.fetchConcurrently(feeds)
+	for i, feed := range feeds {
+		resp, err := results[i].resp, results[i].err
 		if err != nil {
-			log.Printf("[RSS] Error fetching feed from %s: %v", source, err)
+			log.Printf("[RSS] Error fetching feed from %s: %v", feed.Name, err)
+			if err := h.recordFailure(feed, results[i].at, err); err != nil {
+				log.Printf("[RSS DB] Failed to record failure of %s: %v", feed.Name, err)
+			}
+			continue
+		}
+		if resp.NotModified {
+			if err := recordFetch(feed.ID, results[i].at, resp); err != nil {
+				log.Printf("[RSS DB] Failed to record fetch of %s: %v", feed.Name, err)
+			}
+			// Nothing new, answer with what was stored last time
+			items, err := storedItems(feed.Name, feed.ItemLimit)
+			if err != nil {
+				log.Printf("[RSS DB] Failed to load stored items from %s: %v", feed.Name, err)
+			}
+			allNews = append(allNews, items...)
 			continue
 		}
 
-		// Limit to top 5 entries
-		limit := 5
+		entries := resp.Entries
+		limit := feed.ItemLimit
 		if len(entries) < limit {
 			limit = len(entries)
 		}
 
 		// Store in database
-		_, err = h.StoreRSSItems(source, entries[:limit])
+		_, err = h.StoreRSSItems(feed.Name, entries[:limit])
 		if err != nil {
-			log.Printf("[RSS] Error storing RSS items from %s: %v", source, err)
+			log.Printf("[RSS] Error storing RSS items from %s: %v", feed.Name, err)
+			// Without the items stored, the validators would turn the next
+			// fetch into a 304 for items we never kept, so retry in full
+			resp.Validators, resp.FreshFor = feedValidators{}, 0
+		}
+		if err := recordFetch(feed.ID, results[i].at, resp); err != nil {
+			log.Printf("[RSS DB] Failed to record fetch of %s: %v", feed.Name, err)
 		}
 
 		// Convert to response format
 		for i := 0; i < limit; i++ {
 			if strings.TrimSpace(entries[i].Link) != "" {
 				allNews = append(allNews, RSSItem{
-					Source: source,
-					Title:  strings.TrimSpace(entries[i].Title),
-					Link:   strings.TrimSpace(entries[i].Link),
+					Source:      feed.Name,
+					Title:       strings.TrimSpace(entries[i].Title),
+					Link:        strings.TrimSpace(entries[i].Link),
+					PublishedAt: entries[i].Published,
+					Author:      strings.TrimSpace(entries[i].Author),
+					Summary:     plainSummary(firstNonEmpty(entries[i].Summary, entries[i].Content)),
 				})
 			}
 		}
 	}
 
-	return allNews, nil
+	return allNews
 }
 
-// GetNewsFromDB retrieves recent news items from the database
-func (h *Handler) GetNewsFromDB() ([]RSSItem, error) {
-	db := database.GetDB()
+// maxSummaryLength is the number of characters of a summary that are kept
+const maxSummaryLength = 300
 
-	rows, err := db.Query(`
-		SELECT source, title, link
+// plainSummary strips the markup from an item description, collapses its
+// whitespace and truncates it at a word boundary
+func plainSummary(description string) string {
+	text := description
+	if doc, err := goquery.NewDocumentFromReader(strings.NewReader(description)); err == nil {
+		text = doc.Text()
+	}
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= maxSummaryLength {
+		return text
+	}
+	cut := string(runes[:maxSummaryLength])
+	if i := strings.LastIndex(cut, " "); i > maxSummaryLength/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
+
+// publishedOrder sorts items by publication time. Items without a date use
+// the time they were first stored, which also caps dates in the future.
+const publishedOrder = `MIN(COALESCE(datetime(published_at), created_at), created_at)`
+
+// NewsFilter narrows the items returned by GetNewsFromDB
+type NewsFilter struct {
+	Since  *time.Time // Only items published at or after this time
+	Source string     // Feed name, matched case-insensitively
+	Limit  int
+}
+
+// storedItems returns the newest stored items of one source
+func storedItems(source string, limit int) ([]RSSItem, error) {
+	return queryNews(NewsFilter{Source: source, Limit: limit})
+}
+
+func queryNews(filter NewsFilter) ([]RSSItem, error) {
+	var conditions []string
+	var args []interface{}
+	if filter.Since != nil {
+		conditions = append(conditions, publishedOrder+` >= ?`)
+		args = append(args, filter.Since.UTC().Format("2006-01-02 15:04:05"))
+	}
+	if filter.Source != "" {
+		conditions = append(conditions, `source = ? COLLATE NOCASE`)
+		args = append(args, filter.Source)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit)
+
+	rows, err := database.GetDB().Query(`
+		SELECT source, title, link, published_at, summary, author
 		FROM rss_news
-		WHERE created_at >= datetime('now', '-1 hour')
-		ORDER BY created_at DESC
-		LIMIT 25
-	`)
+		`+where+`
+		ORDER BY `+publishedOrder+` DESC, id DESC
+		LIMIT ?
+	`, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var news []RSSItem
+	items := make([]RSSItem, 0)
 	for rows.Next() {
 		var item RSSItem
-		if err := rows.Scan(&item.Source, &item.Title, &item.Link); err != nil {
-			log.Printf("[RSS] Failed to scan news item from database: %v", err)
-			continue
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&item.Source, &item.Title, &item.Link, &publishedAt, &item.Summary, &item.Author); err != nil {
+			return nil, err
+		}
+		if publishedAt.Valid {
+			item.PublishedAt = &publishedAt.Time
 		}
-		news = append(news, item)
+		items = append(items, item)
 	}
+	return items, rows.Err()
+}
+
+// GetNewsFromDB retrieves stored news items, newest publication first
+func (h *Handler) GetNewsFromDB(filter NewsFilter) ([]RSSItem, error) {
+	return queryNews(filter)
+}
 
-	return news, nil
+// feedsAttemptedSince reports whether any enabled feed was fetched after t,
+// successfully or not, so failing feeds don't trigger a fetch per request
+func feedsAttemptedSince(t time.Time) (bool, error) {
+	var count int
+	err := database.GetDB().QueryRow(`
+		SELECT COUNT(*) FROM rss_feeds WHERE enabled = 1 AND last_attempt_at >= ?
+	`, t.UTC()).Scan(&count)
+	return count > 0, err
+}
+
+// parseSince accepts an RFC 3339 time or a YYYY-MM-DD date
+func parseSince(value string) (*time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("Invalid since %q, expected an RFC 3339 time or YYYY-MM-DD", value)
 }
 
-// GetNews retrieves news items, either from DB cache or freshly fetched
+// GetNews returns stored news sorted by publication time. When no feed was
+// fetched within the last hour the due feeds are fetched first, within the
+// same refresh and max-age/<ttl> limits as the scheduler. Supports ?since=,
+// ?source= and ?limit=, e.g. /news?source=Wired&since=2026-10-01
 func (h *Handler) GetNews(c *fiber.Ctx) error {
-	// Try to get from database first
-	news, err := h.GetNewsFromDB()
-	if err == nil && len(news) > 0 {
-		log.Printf("[RSS] Cache hit: Returned %d news items from database", len(news))
-		return c.JSON(news)
+	filter := NewsFilter{Source: strings.TrimSpace(c.Query("source"))}
+
+	limit, err := strconv.Atoi(c.Query("limit", "25"))
+	if err != nil || limit < 1 || limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit, expected a number between 1 and 100",
+		})
 	}
+	filter.Limit = limit
 
-	// If database query failed or returned no results, fetch fresh data
-	if err != nil {
-		log.Printf("[RSS] Cache miss due to DB query error: %v. Fetching fresh data.", err)
-	} else {
-		log.Printf("[RSS] Cache miss: No recent data found in DB. Fetching fresh data.")
+	if value := c.Query("since"); value != "" {
+		since, err := parseSince(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		}
+		filter.Since = since
 	}
 
-	// Fetch fresh data from RSS feeds
-	freshNews, err := h.FetchAllFeeds()
+	fresh, err := feedsAttemptedSince(time.Now().Add(-time.Hour))
+	switch {
+	case err != nil:
+		log.Printf("[RSS] Cache miss due to DB query error: %v. Fetching due feeds.", err)
+	case !fresh:
+		log.Printf("[RSS] Cache miss: No feed fetched within the last hour. Fetching due feeds.")
+	}
+	if err != nil || !fresh {
+		if err := h.FetchDueFeeds(); err != nil {
+			log.Printf("[RSS] Failed to fetch news after cache miss: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": fmt.Sprintf("Failed to fetch news: %v", err),
+			})
+		}
+	}
+
+	news, err := h.GetNewsFromDB(filter)
 	if err != nil {
-		log.Printf("[RSS] Failed to fetch news after cache miss: %v", err)
+		log.Printf("[RSS] Failed to load news from database: %v", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": fmt.Sprintf("Failed to fetch news: %v", err),
+			"error": fmt.Sprintf("Failed to load news: %v", err),
 		})
 	}
 
-	return c.JSON(freshNews)
+	log.Printf("[RSS] Returned %d news items from database", len(news))
+	return c.JSON(news)
 }
 
 // RegisterRoutes registers the RSS endpoints with the Fiber app
@@ -247,18 +490,30 @@ func (h *Handler) RegisterRoutes(app *fiber.App) {
 		Next: func(c *fiber.Ctx) bool {
 			return c.Query("refresh") == "true"
 		},
+		// Key on the supported filters only, so unrelated query strings
+		// share an entry
+		KeyGenerator: func(c *fiber.Ctx) string {
+			return fmt.Sprintf("%s?source=%s&since=%s&limit=%s", c.Path(),
+				strings.ToLower(strings.TrimSpace(c.Query("source"))), c.Query("since"), c.Query("limit"))
+		},
 		Expiration:   15 * time.Minute,
 		CacheControl: true,
 	}
 
 	app.Get("/news", cache.New(cacheConfig), h.GetNews)
+	app.Get("/news/feeds", h.GetFeeds)
+	app.Post("/news/feeds", h.CreateFeed)
+	app.Get("/news/feeds/status", h.GetFeedStatus)
+	app.Post("/news/feeds/import", h.ImportOPML)
+	app.Get("/news/feeds/export.opml", h.ExportOPML)
+	app.Get("/news/feeds/:id", h.GetFeed)
+	app.Put("/news/feeds/:id", h.UpdateFeed)
+	app.Delete("/news/feeds/:id", h.DeleteFeed)
 	log.Printf("[RSS] Routes registered with %v cache expiration", cacheConfig.Expiration)
 }
 
-// AddToJobScheduler adds periodic RSS feed fetching to the scheduler
+// AddToJobScheduler adds periodic RSS feed fetching to the scheduler. The
+// job runs often; each feed is only fetched once its refresh interval passed.
 func (h *Handler) AddToJobScheduler(addJob func(string, time.Duration, func() error)) {
-	addJob("RSS Feeds", 30*time.Minute, func() error {
-		_, err := h.FetchAllFeeds()
-		return err
-	})
+	addJob("RSS Feeds", minRefreshMinutes*time.Minute, h.FetchDueFeeds)
 }
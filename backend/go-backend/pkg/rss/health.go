@@ -0,0 +1,206 @@
+package rss
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// defaultFetchWorkers caps how many feeds are fetched at once unless
+	// RSS_FETCH_CONCURRENCY is set
+	defaultFetchWorkers = 4
+
+	// defaultMaxFailures is how many fetches in a row may fail before a
+	// feed is disabled, unless RSS_MAX_FAILURES is set
+	defaultMaxFailures = 10
+)
+
+// Feed health states reported by /news/feeds/status
+const (
+	FeedPending  = "pending"  // Never fetched
+	FeedOK       = "ok"       // Last fetch succeeded
+	FeedFailing  = "failing"  // Last fetch failed
+	FeedDisabled = "disabled" // Turned off by hand or after too many failures
+)
+
+// FeedHealth is the outcome of a feed's recent fetches
+type FeedHealth struct {
+	LastAttemptAt       *time.Time `json:"lastAttemptAt"`
+	LastSuccessAt       *time.Time `json:"lastSuccessAt"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	LastError           string     `json:"lastError"`
+	// LastStatus is the HTTP status of the last response, 0 when the
+	// request itself failed
+	LastStatus int `json:"lastStatus"`
+	ItemCount  int `json:"itemCount"`
+	// AutoDisabled is set when the feed was disabled for failing too often
+	AutoDisabled bool `json:"autoDisabled"`
+}
+
+// FeedStatus is one entry of GET /news/feeds/status
+type FeedStatus struct {
+	ID      int64  `json:"id"`
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Enabled bool   `json:"enabled"`
+	Status  string `json:"status"`
+	FeedHealth
+}
+
+// httpStatusError is returned for non-OK feed responses, and for OK responses
+// whose body could not be read or parsed, so the status code can be recorded
+type httpStatusError struct {
+	URL    string
+	Code   int
+	Status string
+	Err    error // Set when the response was OK but its body unusable
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("request to %s returned status %s", e.URL, e.Status)
+}
+
+func (e *httpStatusError) Unwrap() error {
+	return e.Err
+}
+
+// positiveIntFromEnv reads a positive integer setting, falling back to def
+func positiveIntFromEnv(name string, def int) int {
+	if value := os.Getenv(name); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("[RSS] Ignoring invalid %s %q", name, value)
+	}
+	return def
+}
+
+// initHealthColumns adds the fetch health columns to rss_feeds
+func initHealthColumns() error {
+	columns := []struct{ name, definition string }{
+		{"last_attempt_at", "TIMESTAMP"},
+		{"consecutive_failures", "INTEGER NOT NULL DEFAULT 0"},
+		{"last_error", "TEXT NOT NULL DEFAULT ''"},
+		{"last_status", "INTEGER NOT NULL DEFAULT 0"},
+		{"item_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"auto_disabled", "BOOLEAN NOT NULL DEFAULT 0"},
+	}
+	for _, column := range columns {
+		if err := database.EnsureColumn("rss_feeds", column.name, column.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// status summarizes the feed's health in one word
+func (f RSSFeed) status() string {
+	switch {
+	case !f.Enabled:
+		return FeedDisabled
+	case f.Health.LastAttemptAt == nil:
+		return FeedPending
+	case f.Health.ConsecutiveFailures > 0:
+		return FeedFailing
+	}
+	return FeedOK
+}
+
+// recordFailure counts a failed fetch and disables the feed once it failed
+// maxFailures times in a row. The count is kept in SQL so fetches of the same
+// feed that overlap don't overwrite each other's failures.
+func (h *Handler) recordFailure(feed RSSFeed, at time.Time, fetchErr error) error {
+	status := 0
+	var statusErr *httpStatusError
+	if errors.As(fetchErr, &statusErr) {
+		status = statusErr.Code
+	}
+
+	var failures int
+	var enabled bool
+	err := database.GetDB().QueryRow(`
+		UPDATE rss_feeds
+		SET last_attempt_at = ?, consecutive_failures = consecutive_failures + 1,
+			last_error = ?, last_status = ?,
+			enabled = CASE WHEN consecutive_failures + 1 >= ? THEN 0 ELSE enabled END,
+			auto_disabled = CASE WHEN consecutive_failures + 1 >= ? THEN 1 ELSE auto_disabled END
+		WHERE id = ?
+		RETURNING consecutive_failures, enabled
+	`, at.UTC(), fetchErr.Error(), status, h.maxFailures, h.maxFailures, feed.ID).Scan(&failures, &enabled)
+	if err != nil {
+		return err
+	}
+
+	if feed.Enabled && !enabled {
+		log.Printf("[RSS] Disabled feed %s after %d failed fetches in a row", feed.Name, failures)
+	}
+	return nil
+}
+
+// fetchResult is the outcome of fetching one feed
+type fetchResult struct {
+	resp *feedResponse
+	err  error
+	at   time.Time
+}
+
+// fetchConcurrently fetches feeds with at most h.fetchWorkers requests in
+// flight. Results are returned in the order of feeds.
+func (h *Handler) fetchConcurrently(feeds []RSSFeed) []fetchResult {
+	results := make([]fetchResult, len(feeds))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < min(h.fetchWorkers, len(feeds)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexes {
+				feed := feeds[index]
+				resp, err := h.fetchFeed(feed.URL, feed.validators)
+				results[index] = fetchResult{resp: resp, err: err, at: time.Now()}
+			}
+		}()
+	}
+
+	for i := range feeds {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// GetFeedStatus reports the fetch health of every feed
+func (h *Handler) GetFeedStatus(c *fiber.Ctx) error {
+	feeds, err := listFeeds(false)
+	if err != nil {
+		return feedError(c, err)
+	}
+
+	statuses := make([]FeedStatus, 0, len(feeds))
+	for _, feed := range feeds {
+		statuses = append(statuses, FeedStatus{
+			ID:         feed.ID,
+			Name:       feed.Name,
+			URL:        feed.URL,
+			Enabled:    feed.Enabled,
+			Status:     feed.status(),
+			FeedHealth: feed.Health,
+		})
+	}
+	return c.JSON(statuses)
+}
@@ -0,0 +1,305 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// opmlDocument is an OPML 2.0 subscription list
+type opmlDocument struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    opmlHead `xml:"head"`
+	Body    opmlBody `xml:"body"`
+}
+
+type opmlHead struct {
+	Title       string `xml:"title,omitempty"`
+	DateCreated string `xml:"dateCreated,omitempty"`
+}
+
+type opmlBody struct {
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// opmlOutline is either a feed (it has an xmlUrl) or a folder of outlines
+type opmlOutline struct {
+	Text     string        `xml:"text,attr"`
+	Title    string        `xml:"title,attr,omitempty"`
+	Type     string        `xml:"type,attr,omitempty"`
+	XMLURL   string        `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string        `xml:"htmlUrl,attr,omitempty"`
+	Category string        `xml:"category,attr,omitempty"`
+	Outlines []opmlOutline `xml:"outline"`
+}
+
+// ImportIssue is a feed of an imported OPML document that was not created
+type ImportIssue struct {
+	Name   string `json:"name"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult is returned by POST /news/feeds/import
+type ImportResult struct {
+	Created []RSSFeed     `json:"created"`
+	Skipped []ImportIssue `json:"skipped"` // Duplicates and invalid entries
+	Failed  []ImportIssue `json:"failed"`  // Feeds that could not be fetched
+}
+
+// opmlFeeds flattens the feed outlines of a document. Folder outlines
+// become the category of the feeds inside them, e.g. "tech/linux".
+func opmlFeeds(outlines []opmlOutline, folders []string) []feedInput {
+	var feeds []feedInput
+	for _, outline := range outlines {
+		text := strings.TrimSpace(firstNonEmpty(outline.Title, outline.Text))
+		if outline.XMLURL == "" {
+			path := folders[:len(folders):len(folders)]
+			if text != "" {
+				path = append(path, text)
+			}
+			feeds = append(feeds, opmlFeeds(outline.Outlines, path)...)
+			continue
+		}
+
+		category := strings.Join(folders, "/")
+		if category == "" {
+			// OPML 2.0 category attributes are comma-separated slash paths
+			first, _, _ := strings.Cut(outline.Category, ",")
+			category = strings.Trim(strings.TrimSpace(first), "/")
+		}
+		name, feedURL := text, strings.TrimSpace(outline.XMLURL)
+		feeds = append(feeds, feedInput{Name: &name, URL: &feedURL, Category: &category})
+	}
+	return feeds
+}
+
+// feedURLKey identifies a feed URL regardless of scheme/host case, a
+// fragment or a trailing slash
+func feedURLKey(feedURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(feedURL))
+	if err != nil {
+		return feedURL
+	}
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.Path = strings.TrimSuffix(parsed.Path, "/")
+	return parsed.String()
+}
+
+// opmlBodyReader returns the OPML document of a request, sent either as the
+// raw body or as a multipart "file" upload
+func opmlBodyReader(c *fiber.Ctx) ([]byte, error) {
+	if !strings.HasPrefix(c.Get(fiber.HeaderContentType), fiber.MIMEMultipartForm) {
+		return c.Body(), nil
+	}
+	header, err := c.FormFile("file")
+	if err != nil {
+		return nil, err
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(file)
+}
+
+// ImportOPML creates feeds from an OPML subscription list. Feeds already
+// subscribed to, or listed twice, are skipped; new feeds are fetched first
+// like feeds created one by one.
+func (h *Handler) ImportOPML(c *fiber.Ctx) error {
+	body, err := opmlBodyReader(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	body, err = toUTF8(body, c.Get(fiber.HeaderContentType))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid OPML document: %v", err),
+		})
+	}
+	var doc opmlDocument
+	if err := unmarshalXML(body, &doc); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid OPML document: %v", err),
+		})
+	}
+
+	existing, err := listFeeds(false)
+	if err != nil {
+		return feedError(c, err)
+	}
+	urls := make(map[string]bool)
+	names := make(map[string]bool)
+	for _, feed := range existing {
+		urls[feedURLKey(feed.URL)] = true
+		names[strings.ToLower(feed.Name)] = true
+	}
+
+	result := ImportResult{Created: []RSSFeed{}, Skipped: []ImportIssue{}, Failed: []ImportIssue{}}
+	var pending []RSSFeed
+	for _, input := range opmlFeeds(doc.Body.Outlines, nil) {
+		if *input.Name == "" {
+			// Untitled outlines are named after the site
+			if parsed, err := url.Parse(*input.URL); err == nil {
+				*input.Name = parsed.Host
+			}
+		}
+		issue := ImportIssue{Name: *input.Name, URL: *input.URL}
+
+		feed := newFeed()
+		if err := input.apply(&feed); err != nil {
+			issue.Reason = err.Error()
+			result.Skipped = append(result.Skipped, issue)
+			continue
+		}
+		key := feedURLKey(feed.URL)
+		if urls[key] {
+			issue.Reason = "already subscribed"
+			result.Skipped = append(result.Skipped, issue)
+			continue
+		}
+
+		// Different feeds with the same title, e.g. two "Blog"s, are told
+		// apart by host
+		if names[strings.ToLower(feed.Name)] {
+			if parsed, err := url.Parse(feed.URL); err == nil {
+				feed.Name = fmt.Sprintf("%s (%s)", feed.Name, parsed.Host)
+			}
+			if err := validateFeedName(feed.Name); err != nil {
+				issue.Reason = err.Error()
+				result.Skipped = append(result.Skipped, issue)
+				continue
+			}
+		}
+		if names[strings.ToLower(feed.Name)] {
+			issue.Reason = "a feed with this name already exists"
+			result.Skipped = append(result.Skipped, issue)
+			continue
+		}
+
+		urls[key] = true
+		names[strings.ToLower(feed.Name)] = true
+		pending = append(pending, feed)
+	}
+
+	for i, fetched := range h.fetchConcurrently(pending) {
+		feed := pending[i]
+		if fetched.err != nil {
+			result.Failed = append(result.Failed, ImportIssue{
+				Name:   feed.Name,
+				URL:    feed.URL,
+				Reason: fmt.Sprintf("Feed could not be fetched and parsed: %v", fetched.err),
+			})
+			continue
+		}
+		created, err := insertFeed(feed)
+		if err != nil {
+			result.Failed = append(result.Failed, ImportIssue{Name: feed.Name, URL: feed.URL, Reason: err.Error()})
+			continue
+		}
+		result.Created = append(result.Created, *created)
+	}
+
+	log.Printf("[RSS] Imported OPML: %d created, %d skipped, %d failed",
+		len(result.Created), len(result.Skipped), len(result.Failed))
+	return c.JSON(result)
+}
+
+// ExportOPML serves every feed as an OPML 2.0 subscription list, with one
+// folder per category
+func (h *Handler) ExportOPML(c *fiber.Ctx) error {
+	feeds, err := listFeeds(false)
+	if err != nil {
+		return feedError(c, err)
+	}
+
+	doc := opmlDocument{
+		Version: "2.0",
+		Head: opmlHead{
+			Title:       "News feeds",
+			DateCreated: time.Now().UTC().Format(time.RFC1123Z),
+		},
+		Body: opmlBody{Outlines: opmlOutlines(feeds)},
+	}
+
+	output, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to encode OPML: %v", err),
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/x-opml; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="feeds.opml"`)
+	return c.Send(append([]byte(xml.Header), output...))
+}
+
+// opmlOutlines lists uncategorized feeds first, then a folder per category
+// in name order. Categories like "tech/linux" become nested folders, the
+// way they are read back on import.
+func opmlOutlines(feeds []RSSFeed) []opmlOutline {
+	root := &opmlFolder{}
+	for _, feed := range feeds {
+		folder := root
+		for _, name := range strings.Split(feed.Category, "/") {
+			if name = strings.TrimSpace(name); name != "" {
+				folder = folder.child(name)
+			}
+		}
+		folder.feeds = append(folder.feeds, opmlOutline{
+			Text:   feed.Name,
+			Title:  feed.Name,
+			Type:   "rss",
+			XMLURL: feed.URL,
+		})
+	}
+	return root.outlines()
+}
+
+// opmlFolder collects the feeds and subfolders of one category level
+type opmlFolder struct {
+	feeds   []opmlOutline
+	folders map[string]*opmlFolder
+}
+
+func (f *opmlFolder) child(name string) *opmlFolder {
+	if f.folders == nil {
+		f.folders = make(map[string]*opmlFolder)
+	}
+	if f.folders[name] == nil {
+		f.folders[name] = &opmlFolder{}
+	}
+	return f.folders[name]
+}
+
+// outlines lists the folder's feeds, then its subfolders in name order
+func (f *opmlFolder) outlines() []opmlOutline {
+	outlines := append([]opmlOutline{}, f.feeds...)
+	names := make([]string, 0, len(f.folders))
+	for name := range f.folders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		outlines = append(outlines, opmlOutline{
+			Text:     name,
+			Title:    name,
+			Outlines: f.folders[name].outlines(),
+		})
+	}
+	return outlines
+}
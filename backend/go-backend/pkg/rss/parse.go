@@ -0,0 +1,576 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Feed formats recognized by ParseFeed
+const (
+	FormatRSS      = "rss"  // RSS 0.9x and 2.0
+	FormatRDF      = "rdf"  // RSS 1.0
+	FormatAtom     = "atom" // Atom 1.0
+	FormatJSONFeed = "json" // JSON Feed 1.x
+)
+
+// ErrUnknownFormat is returned for documents that are not a known feed format
+var ErrUnknownFormat = errors.New("unknown feed format")
+
+// Feed is a parsed feed in any supported format
+type Feed struct {
+	Format string
+	Title  string
+	Link   string
+	// TTL is how long the publisher asks readers to cache the feed (RSS
+	// <ttl>), zero when not given
+	TTL     time.Duration
+	Entries []FeedEntry
+}
+
+// FeedEntry is one item of a feed, normalized across formats
+type FeedEntry struct {
+	// ID is the entry's guid/id, falling back to its link
+	ID    string   `json:"id"`
+	Title string   `json:"title"`
+	Link  string   `json:"link"`
+	Links []string `json:"links,omitempty"`
+	// Published and Updated are nil when missing or unparseable
+	Published *time.Time `json:"published"`
+	Updated   *time.Time `json:"updated"`
+	Author    string     `json:"author,omitempty"`
+	Summary   string     `json:"summary,omitempty"`
+	Content   string     `json:"content,omitempty"`
+}
+
+// XML structures for RSS 2.0 parsing
+type RSS struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel Channel  `xml:"channel"`
+}
+
+type Channel struct {
+	Title       string     `xml:"title"`
+	Description string     `xml:"description"`
+	Links       []rssLink  `xml:"link"`
+	TTL         string     `xml:"ttl"`
+	Items       []RSSEntry `xml:"item"`
+}
+
+type RSSEntry struct {
+	Title       string    `xml:"title"`
+	Links       []rssLink `xml:"link"`
+	Description string    `xml:"description"`
+	PubDate     string    `xml:"pubDate"`
+	GUID        string    `xml:"guid"`
+	Author      string    `xml:"author"`
+	Creator     string    `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Date        string    `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Content     string    `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// rssLink matches both <link>url</link> and the <atom:link href="url"/>
+// elements many RSS 2.0 feeds add next to it
+type rssLink struct {
+	Href string `xml:"href,attr"`
+	Text string `xml:",chardata"`
+}
+
+// rssLinks lists plain links first, so the atom:link rel="self" pointing
+// at the feed itself never becomes the primary link
+func rssLinks(links []rssLink) []string {
+	var plain, atom []string
+	for _, link := range links {
+		if text := strings.TrimSpace(link.Text); text != "" {
+			plain = append(plain, text)
+		} else if href := strings.TrimSpace(link.Href); href != "" {
+			atom = append(atom, href)
+		}
+	}
+	return append(plain, atom...)
+}
+
+// XML structures for RSS 1.0 (RDF), where items are siblings of the channel
+type rdfFeed struct {
+	XMLName xml.Name `xml:"RDF"`
+	Channel struct {
+		Title string `xml:"title"`
+		Link  string `xml:"link"`
+	} `xml:"channel"`
+	Items []rdfItem `xml:"item"`
+}
+
+type rdfItem struct {
+	About       string `xml:"about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Content     string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+}
+
+// XML structures for Atom 1.0
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Title   atomText    `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Authors []atomActor `xml:"author"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     atomText    `xml:"title"`
+	Links     []atomLink  `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Authors   []atomActor `xml:"author"`
+	Summary   atomText    `xml:"summary"`
+	Content   atomText    `xml:"content"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+type atomActor struct {
+	Name string `xml:"name"`
+}
+
+// atomText is a text construct: plain text, escaped HTML or inline XHTML
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return strings.TrimSpace(t.Inner)
+	}
+	return strings.TrimSpace(t.Text)
+}
+
+// alternateLink picks the rel="alternate" link, which is also the default rel
+func alternateLink(links []atomLink) (string, []string) {
+	var primary string
+	var all []string
+	for _, link := range links {
+		if link.Href == "" {
+			continue
+		}
+		all = append(all, link.Href)
+		if primary == "" && (link.Rel == "" || link.Rel == "alternate") {
+			primary = link.Href
+		}
+	}
+	if primary == "" && len(all) > 0 {
+		primary = all[0]
+	}
+	return primary, all
+}
+
+// JSON Feed 1.1, also accepting the 1.0 single author field
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            json.RawMessage  `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Author        *jsonFeedAuthor  `json:"author"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+// DetectFormat looks at the document's first token: a JSON object or the
+// name of the XML root element
+func DetectFormat(body []byte) (string, error) {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(body, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return FormatJSONFeed, nil
+	}
+
+	decoder := newXMLDecoder(trimmed, false)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", ErrUnknownFormat
+		}
+		if err != nil {
+			return "", fmt.Errorf("%w: %v", ErrUnknownFormat, err)
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch start.Name.Local {
+		case "rss":
+			return FormatRSS, nil
+		case "RDF":
+			return FormatRDF, nil
+		case "feed":
+			return FormatAtom, nil
+		}
+		return "", fmt.Errorf("%w: root element <%s>", ErrUnknownFormat, start.Name.Local)
+	}
+}
+
+// ParseFeed detects the feed format and normalizes its entries. Documents
+// in other charsets than UTF-8 must declare theirs in the XML declaration;
+// use ParseFeedWithContentType when an HTTP Content-Type is known.
+func ParseFeed(body []byte) (*Feed, error) {
+	return ParseFeedWithContentType(body, "")
+}
+
+// ParseFeedWithContentType is ParseFeed for documents fetched over HTTP,
+// taking the charset from contentType when it names one
+func ParseFeedWithContentType(body []byte, contentType string) (*Feed, error) {
+	body, err := toUTF8(body, contentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode feed charset: %w", err)
+	}
+
+	format, err := DetectFormat(body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch format {
+	case FormatRSS:
+		return parseRSS(body)
+	case FormatRDF:
+		return parseRDF(body)
+	case FormatAtom:
+		return parseAtom(body)
+	}
+	return parseJSONFeed(body)
+}
+
+func parseRSS(body []byte) (*Feed, error) {
+	var rss RSS
+	if err := unmarshalXML(body, &rss); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:  FormatRSS,
+		Title:   strings.TrimSpace(rss.Channel.Title),
+		Entries: make([]FeedEntry, 0, len(rss.Channel.Items)),
+	}
+	if links := rssLinks(rss.Channel.Links); len(links) > 0 {
+		feed.Link = links[0]
+	}
+	if minutes, err := strconv.Atoi(strings.TrimSpace(rss.Channel.TTL)); err == nil && minutes > 0 {
+		feed.TTL = time.Duration(minutes) * time.Minute
+	}
+	for _, item := range rss.Channel.Items {
+		links := rssLinks(item.Links)
+
+		entry := FeedEntry{
+			ID:        strings.TrimSpace(item.GUID),
+			Title:     strings.TrimSpace(item.Title),
+			Links:     links,
+			Published: parseFeedDate(firstNonEmpty(item.PubDate, item.Date)),
+			Author:    strings.TrimSpace(firstNonEmpty(item.Author, item.Creator)),
+			Summary:   strings.TrimSpace(item.Description),
+			Content:   strings.TrimSpace(item.Content),
+		}
+		if len(links) > 0 {
+			entry.Link = links[0]
+		}
+		feed.Entries = append(feed.Entries, entry.withDefaults())
+	}
+	return feed, nil
+}
+
+func parseRDF(body []byte) (*Feed, error) {
+	var rdf rdfFeed
+	if err := unmarshalXML(body, &rdf); err != nil {
+		return nil, err
+	}
+
+	feed := &Feed{
+		Format:  FormatRDF,
+		Title:   strings.TrimSpace(rdf.Channel.Title),
+		Link:    strings.TrimSpace(rdf.Channel.Link),
+		Entries: make([]FeedEntry, 0, len(rdf.Items)),
+	}
+	for _, item := range rdf.Items {
+		link := strings.TrimSpace(firstNonEmpty(item.Link, item.About))
+		entry := FeedEntry{
+			ID:        strings.TrimSpace(item.About),
+			Title:     strings.TrimSpace(item.Title),
+			Link:      link,
+			Published: parseFeedDate(item.Date),
+			Author:    strings.TrimSpace(item.Creator),
+			Summary:   strings.TrimSpace(item.Description),
+			Content:   strings.TrimSpace(item.Content),
+		}
+		if link != "" {
+			entry.Links = []string{link}
+		}
+		feed.Entries = append(feed.Entries, entry.withDefaults())
+	}
+	return feed, nil
+}
+
+func parseAtom(body []byte) (*Feed, error) {
+	var atom atomFeed
+	if err := unmarshalXML(body, &atom); err != nil {
+		return nil, err
+	}
+
+	feedLink, _ := alternateLink(atom.Links)
+	feed := &Feed{
+		Format:  FormatAtom,
+		Title:   atom.Title.String(),
+		Link:    feedLink,
+		Entries: make([]FeedEntry, 0, len(atom.Entries)),
+	}
+	for _, item := range atom.Entries {
+		link, links := alternateLink(item.Links)
+		// Entries inherit the feed's author when they have none
+		authors := item.Authors
+		if len(authors) == 0 {
+			authors = atom.Authors
+		}
+		names := make([]string, 0, len(authors))
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		entry := FeedEntry{
+			ID:        strings.TrimSpace(item.ID),
+			Title:     item.Title.String(),
+			Link:      link,
+			Links:     links,
+			Published: parseFeedDate(item.Published),
+			Updated:   parseFeedDate(item.Updated),
+			Author:    strings.Join(names, ", "),
+			Summary:   item.Summary.String(),
+			Content:   item.Content.String(),
+		}
+		feed.Entries = append(feed.Entries, entry.withDefaults())
+	}
+	return feed, nil
+}
+
+func parseJSONFeed(body []byte) (*Feed, error) {
+	var doc jsonFeed
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(doc.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("%w: JSON document without a jsonfeed.org version", ErrUnknownFormat)
+	}
+
+	feed := &Feed{
+		Format:  FormatJSONFeed,
+		Title:   strings.TrimSpace(doc.Title),
+		Link:    strings.TrimSpace(doc.HomePageURL),
+		Entries: make([]FeedEntry, 0, len(doc.Items)),
+	}
+	for _, item := range doc.Items {
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []jsonFeedAuthor{*item.Author}
+		}
+		names := make([]string, 0, len(authors))
+		for _, author := range authors {
+			if name := strings.TrimSpace(author.Name); name != "" {
+				names = append(names, name)
+			}
+		}
+
+		var links []string
+		for _, link := range []string{item.URL, item.ExternalURL} {
+			if link = strings.TrimSpace(link); link != "" {
+				links = append(links, link)
+			}
+		}
+
+		entry := FeedEntry{
+			ID:        jsonFeedID(item.ID),
+			Title:     strings.TrimSpace(item.Title),
+			Links:     links,
+			Published: parseFeedDate(item.DatePublished),
+			Updated:   parseFeedDate(item.DateModified),
+			Author:    strings.Join(names, ", "),
+			Summary:   strings.TrimSpace(item.Summary),
+			Content:   strings.TrimSpace(firstNonEmpty(item.ContentHTML, item.ContentText)),
+		}
+		if len(links) > 0 {
+			entry.Link = links[0]
+		}
+		feed.Entries = append(feed.Entries, entry.withDefaults())
+	}
+	return feed, nil
+}
+
+// jsonFeedID accepts ids given as strings or, against the spec, as numbers
+func jsonFeedID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return strings.TrimSpace(id)
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// withDefaults fills in fields some formats leave optional
+func (e FeedEntry) withDefaults() FeedEntry {
+	if e.ID == "" {
+		e.ID = e.Link
+	}
+	if e.Published == nil {
+		e.Published = e.Updated
+	}
+	return e
+}
+
+// feedDateLayouts covers numeric-zone RFC 822 dates used by RSS and RFC 3339
+// dates used by Atom, JSON Feed and Dublin Core. Named zones are left to
+// normalizeRFC822, as time.Parse reads unknown names such as EDT as UTC.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	time.RFC3339,
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+}
+
+// rfc822Layouts are tried on dates normalized by normalizeRFC822, which
+// drops the weekday and turns zone names into numeric offsets
+var rfc822Layouts = []string{
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04 -0700",
+	"2 January 2006 15:04:05 -0700",
+	"2 January 2006 15:04 -0700",
+	"2 Jan 2006 15:04:05.999999999 -0700",
+}
+
+// rfc822Zones are the zone names RFC 822 allows plus common abbreviations
+// feeds use anyway
+var rfc822Zones = map[string]string{
+	"UT": "+0000", "UTC": "+0000", "GMT": "+0000", "Z": "+0000",
+	"EST": "-0500", "EDT": "-0400",
+	"CST": "-0600", "CDT": "-0500",
+	"MST": "-0700", "MDT": "-0600",
+	"PST": "-0800", "PDT": "-0700",
+	"BST": "+0100", "CET": "+0100", "CEST": "+0200",
+	"EET": "+0200", "EEST": "+0300", "IST": "+0530", "JST": "+0900",
+	"AEST": "+1000", "AEDT": "+1100",
+}
+
+var weekdayNames = map[string]bool{
+	"mon": true, "tue": true, "tues": true, "wed": true, "thu": true, "thur": true, "thurs": true,
+	"fri": true, "sat": true, "sun": true, "monday": true, "tuesday": true, "wednesday": true,
+	"thursday": true, "friday": true, "saturday": true, "sunday": true,
+}
+
+func parseFeedDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	for _, layout := range feedDateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+
+	normalized := normalizeRFC822(value)
+	for _, layout := range rfc822Layouts {
+		if t, err := time.Parse(layout, normalized); err == nil {
+			t = t.UTC()
+			return &t
+		}
+	}
+	return nil
+}
+
+// normalizeRFC822 rewrites the RFC 822 variants found in real feeds, e.g.
+// "Tuesday, 7 Oct 2026 9:30 EDT", "Tue,07 Sept 26 09:30:00 GMT+0200" or
+// "07 Oct 2026 09:30:00 +0000 (UTC)", to "2 Jan 2006 15:04:05 -0700" form.
+// A missing zone is taken as UTC.
+func normalizeRFC822(value string) string {
+	if i := strings.Index(value, "("); i > 0 {
+		value = value[:i]
+	}
+	fields := strings.Fields(strings.ReplaceAll(value, ",", " "))
+	if len(fields) > 0 && weekdayNames[strings.ToLower(fields[0])] {
+		fields = fields[1:]
+	}
+	if len(fields) < 4 {
+		return value
+	}
+
+	if strings.EqualFold(fields[1], "Sept") {
+		fields[1] = "Sep"
+	}
+	// Single-digit hours such as 9:30
+	if len(fields[3]) > 0 && strings.Index(fields[3], ":") == 1 {
+		fields[3] = "0" + fields[3]
+	}
+
+	zone := "+0000"
+	if len(fields) > 4 {
+		zone = strings.ToUpper(fields[4])
+		for _, prefix := range []string{"GMT", "UTC"} {
+			if len(zone) > len(prefix) && strings.HasPrefix(zone, prefix) {
+				zone = zone[len(prefix):]
+			}
+		}
+		if offset, ok := rfc822Zones[zone]; ok {
+			zone = offset
+		} else if strings.Trim(zone, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") == "" {
+			// Unknown zone names are read as UTC, like time.Parse does
+			zone = "+0000"
+		}
+		// "+02:00" and "+2" style offsets
+		zone = strings.ReplaceAll(zone, ":", "")
+		if len(zone) == 2 || len(zone) == 3 {
+			if hours, err := strconv.Atoi(zone[1:]); err == nil && (zone[0] == '+' || zone[0] == '-') {
+				zone = fmt.Sprintf("%c%02d00", zone[0], hours)
+			}
+		}
+	}
+
+	return strings.Join(append(fields[:4], zone), " ")
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
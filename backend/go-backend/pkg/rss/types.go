@@ -1,19 +1,39 @@
 package rss
 
+import "time"
+
 // RSSItem represents a news item from an RSS feed
 type RSSItem struct {
-	Source string `json:"source"`
-	Title  string `json:"title"`
-	Link   string `json:"link"`
+	Source      string     `json:"source"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	PublishedAt *time.Time `json:"publishedAt"`
+	Author      string     `json:"author,omitempty"`
+	// Summary is the item's description as plain text, truncated
+	Summary string `json:"summary,omitempty"`
 }
 
-// RSSFeed represents a news source with its URL
+// RSSFeed is a subscribed news source, stored in the rss_feeds table
 type RSSFeed struct {
-	Name string
-	URL  string
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	Category string `json:"category"`
+	// ItemLimit is how many of the newest entries are kept per fetch
+	ItemLimit int  `json:"itemLimit"`
+	Enabled   bool `json:"enabled"`
+	// RefreshMinutes is the minimum time between fetches of this feed
+	RefreshMinutes int        `json:"refreshMinutes"`
+	LastFetchedAt  *time.Time `json:"lastFetchedAt"`
+	// FreshUntil is when the server's max-age or the feed's <ttl> runs out
+	FreshUntil *time.Time `json:"freshUntil"`
+	Health     FeedHealth `json:"health"`
+	CreatedAt  time.Time  `json:"createdAt"`
+
+	validators feedValidators
 }
 
-// RSSFeedList is a map of feed names to feed URLs
+// RSSFeedList seeds the rss_feeds table on first run
 var RSSFeedList = map[string]string{
 	"TechCrunch":   "http://feeds.feedburner.com/TechCrunch/",
 	"Wired":        "https://www.wired.com/feed/rss",
@@ -0,0 +1,340 @@
+package tickers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var errAlertNotFound = errors.New("alert not found")
+
+// US markets set the trading day for symbols without a market time
+var tradingDayLocation = MustLoadLocation("America/New_York")
+
+// alertMetrics maps the metric names accepted in rules to TickerData fields
+var alertMetrics = map[string]func(TickerData) *float64{
+	"price":           func(d TickerData) *float64 { return d.TodaysPrice },
+	"dayChange":       func(d TickerData) *float64 { return d.DayChange },
+	"weekChange":      func(d TickerData) *float64 { return d.WeekChange },
+	"monthChange":     func(d TickerData) *float64 { return d.MonthChange },
+	"ytdChange":       func(d TickerData) *float64 { return d.YTDChange },
+	"yearChange":      func(d TickerData) *float64 { return d.YearChange },
+	"percentFromHigh": func(d TickerData) *float64 { return d.PercentFromHigh },
+}
+
+var alertOperators = map[string]func(value, threshold float64) bool{
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+}
+
+// Alert is a rule such as "SPY dayChange < -2" delivered through a notifier
+type Alert struct {
+	ID        int64   `json:"id"`
+	Symbol    string  `json:"symbol"`
+	Metric    string  `json:"metric"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+	Notifier  string  `json:"notifier"`
+	Target    string  `json:"target"`
+	// LastFiredOn is the trading day (YYYY-MM-DD) the alert last fired
+	LastFiredOn string    `json:"lastFiredOn,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// initAlertTables creates the table holding alert rules
+func initAlertTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_alerts (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			symbol TEXT NOT NULL,
+			metric TEXT NOT NULL,
+			operator TEXT NOT NULL,
+			threshold REAL NOT NULL,
+			notifier TEXT NOT NULL,  -- webhook or ntfy
+			target TEXT NOT NULL,    -- URL the notifier posts to
+			last_fired_on TEXT,      -- Trading day, at most one firing per day
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// String describes the rule, e.g. "SPY dayChange < -2"
+func (a Alert) String() string {
+	return fmt.Sprintf("%s %s %s %s", a.Symbol, a.Metric, a.Operator, strconv.FormatFloat(a.Threshold, 'f', -1, 64))
+}
+
+func listAlerts() ([]Alert, error) {
+	return queryAlerts(`ORDER BY id ASC`)
+}
+
+func getAlert(id int64) (*Alert, error) {
+	alerts, err := queryAlerts(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(alerts) == 0 {
+		return nil, errAlertNotFound
+	}
+	return &alerts[0], nil
+}
+
+func queryAlerts(clause string, args ...interface{}) ([]Alert, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT id, symbol, metric, operator, threshold, notifier, target,
+		       COALESCE(last_fired_on, ''), created_at
+		FROM ticker_alerts
+		`+clause, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := make([]Alert, 0)
+	for rows.Next() {
+		var a Alert
+		err := rows.Scan(&a.ID, &a.Symbol, &a.Metric, &a.Operator, &a.Threshold,
+			&a.Notifier, &a.Target, &a.LastFiredOn, &a.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, rows.Err()
+}
+
+// alertSymbols returns the distinct symbols that have alert rules
+func alertSymbols() ([]string, error) {
+	rows, err := database.GetDB().Query(`SELECT DISTINCT symbol FROM ticker_alerts ORDER BY symbol`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var symbols []string
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, rows.Err()
+}
+
+// tradingDay is the exchange-local date of a quote
+func tradingDay(data TickerData) string {
+	if data.AsOf != nil {
+		return data.AsOf.Format("2006-01-02")
+	}
+	return time.Now().In(tradingDayLocation).Format("2006-01-02")
+}
+
+// evaluateAlerts fires every rule whose condition holds for the fresh
+// quotes in data, at most once per trading day. Stale quotes are skipped
+// so a provider outage can't re-trigger yesterday's move.
+func (h *Handler) evaluateAlerts(data []TickerData) error {
+	alerts, err := listAlerts()
+	if err != nil {
+		return err
+	}
+
+	quotes := make(map[string]TickerData, len(data))
+	for _, d := range data {
+		if !d.Stale {
+			quotes[d.Ticker] = d
+		}
+	}
+
+	for _, alert := range alerts {
+		quote, ok := quotes[alert.Symbol]
+		if !ok {
+			continue
+		}
+
+		day := tradingDay(quote)
+		if alert.LastFiredOn == day {
+			continue
+		}
+
+		value := alertMetrics[alert.Metric](quote)
+		if value == nil || !alertOperators[alert.Operator](*value, alert.Threshold) {
+			continue
+		}
+
+		if err := h.fireAlert(alert, quote, *value); err != nil {
+			log.Printf("[Stocks] Failed to deliver alert %d (%s): %v", alert.ID, alert, err)
+			continue
+		}
+
+		_, err := database.GetDB().Exec(`
+			UPDATE ticker_alerts SET last_fired_on = ? WHERE id = ?
+		`, day, alert.ID)
+		if err != nil {
+			return err
+		}
+		log.Printf("[Stocks] Fired alert %d (%s) for %s", alert.ID, alert, day)
+	}
+
+	return nil
+}
+
+func (h *Handler) fireAlert(alert Alert, quote TickerData, value float64) error {
+	notifier, err := newNotifier(alert.Notifier, alert.Target, h.client)
+	if err != nil {
+		return err
+	}
+
+	return notifier.Notify(Notification{
+		Title:   fmt.Sprintf("Alert: %s", alert),
+		Message: fmt.Sprintf("%s %s is %.2f (rule: %s %s)", alert.Symbol, alert.Metric, value, alert.Operator, strconv.FormatFloat(alert.Threshold, 'f', -1, 64)),
+		Alert:   alert,
+		Ticker:  quote,
+	})
+}
+
+func alertError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errAlertNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	log.Printf("[Stocks] Alert database error: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Alert database error: %v", err),
+	})
+}
+
+func alertIDParam(c *fiber.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return 0, errAlertNotFound
+	}
+	return id, nil
+}
+
+// GetAlerts lists every alert rule
+func (h *Handler) GetAlerts(c *fiber.Ctx) error {
+	alerts, err := listAlerts()
+	if err != nil {
+		return alertError(c, err)
+	}
+	return c.JSON(alerts)
+}
+
+// CreateAlert adds a rule, e.g.
+// {"symbol": "SPY", "metric": "dayChange", "operator": "<", "threshold": -2,
+// "notifier": "ntfy", "target": "https://ntfy.sh/markets"}
+func (h *Handler) CreateAlert(c *fiber.Ctx) error {
+	var body Alert
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	if _, ok := alertMetrics[body.Metric]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid metric %q, expected one of: %s", body.Metric, strings.Join(sortedKeys(alertMetrics), ", ")),
+		})
+	}
+	if _, ok := alertOperators[body.Operator]; !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid operator %q, expected one of: %s", body.Operator, strings.Join(sortedKeys(alertOperators), ", ")),
+		})
+	}
+	if _, err := newNotifier(body.Notifier, body.Target, h.client); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	symbol, err := h.validateSymbol(body.Symbol)
+	if err != nil {
+		return symbolError(c, err)
+	}
+
+	result, err := database.GetDB().Exec(`
+		INSERT INTO ticker_alerts (symbol, metric, operator, threshold, notifier, target)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, symbol, body.Metric, body.Operator, body.Threshold, body.Notifier, body.Target)
+	if err != nil {
+		return alertError(c, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	alert, err := getAlert(id)
+	if err != nil {
+		return alertError(c, err)
+	}
+	log.Printf("[Stocks] Created alert %d (%s)", alert.ID, alert)
+	return c.Status(fiber.StatusCreated).JSON(alert)
+}
+
+// DeleteAlert removes a rule
+func (h *Handler) DeleteAlert(c *fiber.Ctx) error {
+	id, err := alertIDParam(c)
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	result, err := database.GetDB().Exec(`DELETE FROM ticker_alerts WHERE id = ?`, id)
+	if err != nil {
+		return alertError(c, err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return alertError(c, errAlertNotFound)
+	}
+
+	log.Printf("[Stocks] Deleted alert %d", id)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// TestAlert sends a notification for a rule right away, whether or not its
+// condition holds, to check the notifier target
+func (h *Handler) TestAlert(c *fiber.Ctx) error {
+	id, err := alertIDParam(c)
+	if err != nil {
+		return alertError(c, err)
+	}
+	alert, err := getAlert(id)
+	if err != nil {
+		return alertError(c, err)
+	}
+
+	quote := TickerData{Ticker: alert.Symbol}
+	if stored, ok, _ := loadStoredQuote(alert.Symbol); ok {
+		quote = stored
+	}
+	var value float64
+	if v := alertMetrics[alert.Metric](quote); v != nil {
+		value = *v
+	}
+
+	if err := h.fireAlert(*alert, quote, value); err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to deliver test notification: %v", err),
+		})
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
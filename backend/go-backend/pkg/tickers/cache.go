@@ -1,53 +1,111 @@
 package tickers
 
 import (
+	"slices"
 	"sync"
 	"time"
 )
 
 type cachedData struct {
 	data      []TickerData
-	timestamp time.Time
+	expiresAt time.Time
+}
+
+// cachedSymbol is a quote fetched outside any watchlist
+type cachedSymbol struct {
+	data      TickerData
+	expiresAt time.Time
 }
 
 var (
-	cache      *cachedData
+	// cache holds the latest ticker data keyed by watchlist name
+	cache      = make(map[string]*cachedData)
 	cacheMutex sync.RWMutex
 	cacheTime  = 5 * time.Minute
+
+	// symbolCache holds quotes of symbols in no watchlist, such as
+	// portfolio holdings, keyed by symbol. Guarded by cacheMutex.
+	symbolCache = make(map[string]cachedSymbol)
 )
 
-func getCachedData() ([]TickerData, bool) {
+func getCachedData(watchlist string) ([]TickerData, bool) {
 	cacheMutex.RLock()
 	defer cacheMutex.RUnlock()
 
-	if cache == nil {
+	entry, ok := cache[watchlist]
+	if !ok {
 		return nil, false
 	}
 
-	if time.Since(cache.timestamp) > cacheTime {
+	if time.Now().After(entry.expiresAt) {
 		return nil, false
 	}
 
-	return cache.data, true
+	// Callers may reorder or modify the result, so hand out a copy
+	return slices.Clone(entry.data), true
 }
 
-func updateCache(data []TickerData) {
+func updateCache(watchlist string, data []TickerData) {
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
 
-	cache = &cachedData{
-		data:      data,
-		timestamp: time.Now(),
+	cache[watchlist] = &cachedData{
+		data:      slices.Clone(data),
+		expiresAt: time.Now().Add(cacheTime),
 	}
 }
 
-// ExtendCacheTime can be called to increase cache time when rate limiting is happening
+// cachedQuote finds a symbol in any unexpired watchlist entry
+func cachedQuote(symbol string) (TickerData, bool) {
+	cacheMutex.RLock()
+	defer cacheMutex.RUnlock()
+
+	now := time.Now()
+	for _, entry := range cache {
+		if now.After(entry.expiresAt) {
+			continue
+		}
+		for _, data := range entry.data {
+			if data.Ticker == symbol {
+				return data, true
+			}
+		}
+	}
+	if entry, ok := symbolCache[symbol]; ok && now.Before(entry.expiresAt) {
+		return entry.data, true
+	}
+	return TickerData{}, false
+}
+
+// updateSymbolCache keeps quotes fetched outside a watchlist for cacheTime
+func updateSymbolCache(data []TickerData) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	expiresAt := time.Now().Add(cacheTime)
+	for _, d := range data {
+		symbolCache[d.Ticker] = cachedSymbol{data: d, expiresAt: expiresAt}
+	}
+}
+
+// invalidateCache drops a watchlist's cached data after its symbols change
+func invalidateCache(watchlist string) {
+	cacheMutex.Lock()
+	defer cacheMutex.Unlock()
+
+	delete(cache, watchlist)
+}
+
+// ExtendCacheTime keeps cached entries alive for at least another
+// additional duration, e.g. while a provider is rate limiting us
 func ExtendCacheTime(additional time.Duration) {
 	cacheMutex.Lock()
 	defer cacheMutex.Unlock()
 
-	if cache != nil {
-		// Update the timestamp to extend the cache lifetime
-		cache.timestamp = time.Now()
+	extended := time.Now().Add(additional)
+	for _, entry := range cache {
+		if entry.expiresAt.Before(extended) {
+			entry.expiresAt = extended
+		}
 	}
 }
@@ -0,0 +1,66 @@
+package tickers
+
+import "time"
+
+// priceChanges holds the percent change of the current price over each
+// window. A nil value means the series did not reach back far enough.
+type priceChanges struct {
+	Day   *float64
+	Week  *float64
+	Month *float64
+	YTD   *float64
+	Year  *float64
+}
+
+// computeChanges derives every change window from one daily series.
+// asOf should be the market time in the exchange's timezone so bars are
+// compared by trading date. fallbackYearClose is used when the series
+// starts after the one-year mark, as Yahoo's range=1y does.
+func computeChanges(price float64, asOf time.Time, points []PricePoint, fallbackYearClose float64) priceChanges {
+	loc := asOf.Location()
+	today := civilDate(asOf)
+
+	var changes priceChanges
+	if prev, ok := closeBefore(points, today, loc); ok {
+		changes.Day = percentChange(price, prev)
+	}
+	if prev, ok := closeBefore(points, today.AddDate(0, 0, -7).AddDate(0, 0, 1), loc); ok {
+		changes.Week = percentChange(price, prev)
+	}
+	if prev, ok := closeBefore(points, today.AddDate(0, -1, 0).AddDate(0, 0, 1), loc); ok {
+		changes.Month = percentChange(price, prev)
+	}
+	if prev, ok := closeBefore(points, time.Date(today.Year(), time.January, 1, 0, 0, 0, 0, time.UTC), loc); ok {
+		changes.YTD = percentChange(price, prev)
+	}
+	if prev, ok := closeBefore(points, today.AddDate(-1, 0, 0).AddDate(0, 0, 1), loc); ok {
+		changes.Year = percentChange(price, prev)
+	} else if fallbackYearClose != 0 {
+		changes.Year = percentChange(price, fallbackYearClose)
+	}
+
+	return changes
+}
+
+// closeBefore returns the close of the last bar dated strictly before day
+func closeBefore(points []PricePoint, day time.Time, loc *time.Location) (float64, bool) {
+	for i := len(points) - 1; i >= 0; i-- {
+		if civilDate(time.Unix(points[i].Time, 0).In(loc)).Before(day) {
+			return points[i].Close, true
+		}
+	}
+	return 0, false
+}
+
+// civilDate strips the time of day, keeping the calendar date as seen in t's location
+func civilDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func percentChange(current, previous float64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	change := ((current - previous) / previous) * 100
+	return &change
+}
@@ -0,0 +1,183 @@
+package tickers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// fxCacheTime is how long a fetched exchange rate is reused
+const fxCacheTime = 5 * time.Minute
+
+var currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// cryptoPairRegex matches crypto pairs such as BTC-USD, but not share
+// classes such as BRK-B
+var cryptoPairRegex = regexp.MustCompile(`^[A-Z0-9]+-[A-Z]{3}$`)
+
+// isCryptoPair reports whether symbol is a crypto pair quoted in the
+// currency after the hyphen
+func isCryptoPair(symbol string) bool {
+	return cryptoPairRegex.MatchString(strings.ToUpper(symbol))
+}
+
+// exchangeSuffixCurrencies maps Yahoo exchange suffixes to their trading currency
+var exchangeSuffixCurrencies = map[string]string{
+	".L":  "GBp",
+	".TO": "CAD",
+	".V":  "CAD",
+	".DE": "EUR",
+	".F":  "EUR",
+	".PA": "EUR",
+	".AS": "EUR",
+	".MI": "EUR",
+	".MC": "EUR",
+	".SW": "CHF",
+	".T":  "JPY",
+	".HK": "HKD",
+	".AX": "AUD",
+	".NS": "INR",
+	".BO": "INR",
+}
+
+// inferCurrency guesses a symbol's currency for providers that don't report
+// one: FX pairs (EURUSD=X) and crypto pairs (BTC-USD) are quoted in their
+// second currency, exchange suffixes imply a market, and the rest are US listings.
+func inferCurrency(symbol string) string {
+	symbol = strings.ToUpper(symbol)
+	switch {
+	case strings.HasSuffix(symbol, "=X") && len(symbol) == 8:
+		return symbol[3:6]
+	case isCryptoPair(symbol):
+		return symbol[strings.LastIndex(symbol, "-")+1:]
+	}
+	if dot := strings.LastIndex(symbol, "."); dot > 0 {
+		if currency, ok := exchangeSuffixCurrencies[symbol[dot:]]; ok {
+			return currency
+		}
+	}
+	return "USD"
+}
+
+// normalizeCurrency turns minor units into their major currency, e.g.
+// London prices in pence (GBp) become GBP with a 1/100 factor
+func normalizeCurrency(currency string) (string, float64) {
+	switch currency {
+	case "GBp", "GBX":
+		return "GBP", 0.01
+	case "ZAc":
+		return "ZAR", 0.01
+	case "ILA":
+		return "ILS", 0.01
+	}
+	return strings.ToUpper(currency), 1
+}
+
+type fxRate struct {
+	rate      float64
+	fetchedAt time.Time
+}
+
+var (
+	fxCache      = make(map[string]fxRate)
+	fxCacheMutex sync.Mutex
+)
+
+// fxSymbol is the Yahoo-style symbol quoting one unit of from in to
+func fxSymbol(from, to string) string {
+	return from + to + "=X"
+}
+
+// exchangeRate returns how many units of to one unit of from is worth,
+// fetching the FX pair through the same provider as other quotes
+func (h *Handler) exchangeRate(from, to string) (float64, error) {
+	from, fromFactor := normalizeCurrency(from)
+	if from == to {
+		return fromFactor, nil
+	}
+
+	symbol := fxSymbol(from, to)
+
+	fxCacheMutex.Lock()
+	cached, ok := fxCache[symbol]
+	fxCacheMutex.Unlock()
+	if ok && time.Since(cached.fetchedAt) < fxCacheTime {
+		return cached.rate * fromFactor, nil
+	}
+
+	quote, err := h.provider.Quote(symbol)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch exchange rate %s: %w", symbol, err)
+	}
+	if quote.Price <= 0 {
+		return 0, fmt.Errorf("invalid exchange rate %s: %v", symbol, quote.Price)
+	}
+
+	fxCacheMutex.Lock()
+	fxCache[symbol] = fxRate{rate: quote.Price, fetchedAt: time.Now()}
+	fxCacheMutex.Unlock()
+
+	return quote.Price * fromFactor, nil
+}
+
+// convertTickers returns copies of data with prices expressed in base.
+// Percent changes are left as measured in each symbol's own currency. A
+// symbol whose exchange rate can't be fetched keeps its own currency and
+// is flagged Unconverted rather than failing the rest.
+func (h *Handler) convertTickers(data []TickerData, base string) []TickerData {
+	// Each currency is looked up once, so a failing pair isn't retried
+	// for every symbol quoted in it
+	type lookup struct {
+		rate float64
+		err  error
+	}
+	rates := make(map[string]lookup)
+
+	converted := make([]TickerData, len(data))
+	for i, d := range data {
+		if d.Currency == "" {
+			d.Currency = inferCurrency(d.Ticker)
+		}
+		result, ok := rates[d.Currency]
+		if !ok {
+			result.rate, result.err = h.exchangeRate(d.Currency, base)
+			rates[d.Currency] = result
+			if result.err != nil {
+				log.Printf("[Stocks] Failed to convert %s prices to %s: %v", d.Currency, base, result.err)
+			}
+		}
+		if result.err != nil {
+			d.Unconverted = true
+			converted[i] = d
+			continue
+		}
+		converted[i] = d.convert(result.rate, base)
+	}
+	return converted
+}
+
+// convert returns a copy of d with every price field multiplied by rate
+func (d TickerData) convert(rate float64, currency string) TickerData {
+	scale := func(value *float64) *float64 {
+		if value == nil {
+			return nil
+		}
+		scaled := *value * rate
+		return &scaled
+	}
+
+	d.TodaysPrice = scale(d.TodaysPrice)
+	d.DayHigh = scale(d.DayHigh)
+	d.DayLow = scale(d.DayLow)
+	d.FiftyTwoWeekHigh = scale(d.FiftyTwoWeekHigh)
+	d.FiftyTwoWeekLow = scale(d.FiftyTwoWeekLow)
+	d.SMA200 = scale(d.SMA200)
+	d.PreMarketPrice = scale(d.PreMarketPrice)
+	d.PostMarketPrice = scale(d.PostMarketPrice)
+	d.Currency = currency
+	d.FXRate = &rate
+	return d
+}
@@ -0,0 +1,310 @@
+package tickers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Corporate event types
+const (
+	EventDividend = "dividend"
+	EventSplit    = "split"
+	EventEarnings = "earnings"
+)
+
+const (
+	// eventsMaxAge is how long stored events are trusted before refetching
+	eventsMaxAge = 12 * time.Hour
+
+	// Events are fetched for this far around today, so announced
+	// ex-dividend and earnings dates are included
+	eventsLookback  = 365 * 24 * time.Hour
+	eventsLookahead = 180 * 24 * time.Hour
+
+	// Default window of GET /tickers/events around today
+	defaultEventsBefore = 90
+	defaultEventsAfter  = 90
+)
+
+// Event is a dividend (by ex-date), split or earnings date for a symbol
+type Event struct {
+	Symbol   string   `json:"symbol"`
+	Type     string   `json:"type"`
+	Date     string   `json:"date"` // YYYY-MM-DD in the exchange's timezone
+	Amount   *float64 `json:"amount,omitempty"`
+	Currency string   `json:"currency,omitempty"`
+	Ratio    string   `json:"ratio,omitempty"`
+}
+
+// initEventTables creates the tables holding corporate events
+func initEventTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_events (
+			symbol TEXT NOT NULL,
+			type TEXT NOT NULL,
+			date TEXT NOT NULL,
+			amount REAL,
+			currency TEXT,
+			ratio TEXT,
+			PRIMARY KEY (symbol, type, date)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_event_sync (
+			symbol TEXT PRIMARY KEY,
+			fetched_at TIMESTAMP NOT NULL
+		)
+	`)
+	return err
+}
+
+// syncEvents refetches a symbol's events when the stored ones are older
+// than eventsMaxAge
+func (h *Handler) syncEvents(symbol string) error {
+	provider, ok := h.provider.(EventProvider)
+	if !ok {
+		return fmt.Errorf("market data provider does not support corporate events")
+	}
+
+	db := database.GetDB()
+	var fetchedAt time.Time
+	err := db.QueryRow(`SELECT fetched_at FROM ticker_event_sync WHERE symbol = ?`, symbol).Scan(&fetchedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && time.Since(fetchedAt) < eventsMaxAge {
+		return nil
+	}
+
+	now := time.Now()
+	events, err := provider.Events(symbol, now.Add(-eventsLookback), now.Add(eventsLookahead))
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Replace the whole fetched window so moved or cancelled dates disappear
+	from := now.Add(-eventsLookback).In(tradingDayLocation).Format("2006-01-02")
+	to := now.Add(eventsLookahead).In(tradingDayLocation).Format("2006-01-02")
+	_, err = tx.Exec(`
+		DELETE FROM ticker_events WHERE symbol = ? AND date BETWEEN ? AND ?
+	`, symbol, from, to)
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO ticker_events (symbol, type, date, amount, currency, ratio)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, symbol, event.Type, event.Date, event.Amount, event.Currency, event.Ratio)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO ticker_event_sync (symbol, fetched_at) VALUES (?, ?)
+	`, symbol, now.UTC())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// storedEvents returns events for symbols between from and to (inclusive
+// YYYY-MM-DD dates), ordered by date
+func storedEvents(symbols []string, from, to string) ([]Event, error) {
+	if len(symbols) == 0 {
+		return []Event{}, nil
+	}
+
+	args := []interface{}{from, to}
+	for _, symbol := range symbols {
+		args = append(args, symbol)
+	}
+	placeholders := strings.TrimSuffix(strings.Repeat("?,", len(symbols)), ",")
+
+	rows, err := database.GetDB().Query(`
+		SELECT symbol, type, date, amount, COALESCE(currency, ''), COALESCE(ratio, '')
+		FROM ticker_events
+		WHERE date >= ? AND date <= ? AND symbol IN (`+placeholders+`)
+		ORDER BY date ASC, symbol ASC, type ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := make([]Event, 0)
+	for rows.Next() {
+		var event Event
+		var amount sql.NullFloat64
+		if err := rows.Scan(&event.Symbol, &event.Type, &event.Date, &amount, &event.Currency, &event.Ratio); err != nil {
+			return nil, err
+		}
+		if amount.Valid {
+			event.Amount = &amount.Float64
+		}
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
+
+// loadEvents reads the query parameters shared by the JSON and iCalendar
+// endpoints, syncs the symbols and returns their stored events
+func (h *Handler) loadEvents(c *fiber.Ctx) ([]Event, error) {
+	today := time.Now().In(tradingDayLocation)
+	from := c.Query("from", today.AddDate(0, 0, -defaultEventsBefore).Format("2006-01-02"))
+	to := c.Query("to", today.AddDate(0, 0, defaultEventsAfter).Format("2006-01-02"))
+	for _, date := range []string{from, to} {
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, fmt.Sprintf("Invalid date %q, expected YYYY-MM-DD", date))
+		}
+	}
+
+	var symbols []string
+	if raw := c.Query("symbols"); raw != "" {
+		for _, value := range strings.Split(raw, ",") {
+			symbol, err := normalizeSymbol(value)
+			if err != nil {
+				return nil, fiber.NewError(fiber.StatusBadRequest, err.Error())
+			}
+			symbols = append(symbols, symbol)
+		}
+	} else {
+		name := c.Query("watchlist", DefaultWatchlist)
+		watchlist, err := getWatchlist(name)
+		switch {
+		case err == nil:
+			symbols = watchlist.Symbols
+		case errors.Is(err, errWatchlistNotFound) && name == DefaultWatchlist:
+			symbols = DefaultTickers
+		case errors.Is(err, errWatchlistNotFound):
+			return nil, fiber.NewError(fiber.StatusNotFound, fmt.Sprintf("Watchlist %q not found", name))
+		default:
+			return nil, err
+		}
+	}
+
+	for _, symbol := range symbols {
+		if err := h.syncEvents(symbol); err != nil {
+			// Serve whatever was stored earlier
+			log.Printf("[Stocks] Failed to fetch events for %s: %v", symbol, err)
+		}
+	}
+
+	return storedEvents(symbols, from, to)
+}
+
+// eventsError responds to a failed loadEvents
+func eventsError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+	}
+	log.Printf("[Stocks] Failed to load events: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Failed to load events: %v", err),
+	})
+}
+
+// GetEvents lists dividends, splits and earnings dates for a watchlist or
+// explicit symbols, e.g. /tickers/events?from=2026-01-01&to=2026-12-31&symbols=SCHD,REIT
+func (h *Handler) GetEvents(c *fiber.Ctx) error {
+	events, err := h.loadEvents(c)
+	if err != nil {
+		return eventsError(c, err)
+	}
+	return c.JSON(events)
+}
+
+// GetEventsCalendar serves the same events as an iCalendar feed that
+// calendar apps can subscribe to
+func (h *Handler) GetEventsCalendar(c *fiber.Ctx) error {
+	events, err := h.loadEvents(c)
+	if err != nil {
+		return eventsError(c, err)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="ticker-events.ics"`)
+	return c.SendString(eventsICalendar(events, time.Now()))
+}
+
+// eventsICalendar renders events as all-day VEVENTs
+func eventsICalendar(events []Event, now time.Time) string {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date < events[j].Date })
+
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(fmt.Sprintf(format, args...))
+		b.WriteString("\r\n")
+	}
+
+	stamp := now.UTC().Format("20060102T150405Z")
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//today//Ticker Events//EN")
+	line("CALSCALE:GREGORIAN")
+	line("X-WR-CALNAME:Ticker events")
+	for _, event := range events {
+		date, err := time.Parse("2006-01-02", event.Date)
+		if err != nil {
+			continue
+		}
+		line("BEGIN:VEVENT")
+		line("UID:%s-%s-%s@today", event.Symbol, event.Type, date.Format("20060102"))
+		line("DTSTAMP:%s", stamp)
+		line("DTSTART;VALUE=DATE:%s", date.Format("20060102"))
+		line("DTEND;VALUE=DATE:%s", date.AddDate(0, 0, 1).Format("20060102"))
+		line("SUMMARY:%s", escapeICalText(eventSummary(event)))
+		line("CATEGORIES:%s", strings.ToUpper(event.Type))
+		line("TRANSP:TRANSPARENT")
+		line("END:VEVENT")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+func eventSummary(event Event) string {
+	switch event.Type {
+	case EventDividend:
+		if event.Amount != nil {
+			return fmt.Sprintf("%s ex-dividend %s %s", event.Symbol, formatAmount(*event.Amount), event.Currency)
+		}
+		return event.Symbol + " ex-dividend"
+	case EventSplit:
+		return fmt.Sprintf("%s split %s", event.Symbol, event.Ratio)
+	case EventEarnings:
+		return event.Symbol + " earnings"
+	}
+	return event.Symbol + " " + event.Type
+}
+
+func formatAmount(amount float64) string {
+	return strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.4f", amount), "0"), ".")
+}
+
+// escapeICalText escapes the characters RFC 5545 reserves in TEXT values
+func escapeICalText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`).Replace(text)
+}
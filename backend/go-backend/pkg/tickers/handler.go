@@ -1,51 +1,318 @@
 package tickers
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"time"
+
+	"go-backend/pkg/database"
+	"go-backend/pkg/rss"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-type Handler struct{}
+// Sort orders supported by GET /tickers
+const (
+	SortWatchlist = "watchlist"
+	SortChange    = "change"
+)
+
+type Handler struct {
+	provider  Provider
+	client    *http.Client
+	feeds     FeedFetcher
+	newsFeeds []newsFeed
+	benchmark string
+}
 
 func NewHandler() *Handler {
-	return &Handler{}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+	}
+	return &Handler{
+		provider:  newProvidersFromEnv(client),
+		client:    client,
+		feeds:     rss.NewHandler(),
+		newsFeeds: newsFeedsFromEnv(),
+		benchmark: benchmarkFromEnv(),
+	}
+}
+
+// Initialize creates the ticker tables if they don't exist
+func (h *Handler) Initialize() error {
+	db := database.GetDB()
+
+	if err := initWatchlistTables(db); err != nil {
+		return err
+	}
+	if err := initHistoryTables(db); err != nil {
+		return err
+	}
+	if err := initQuoteTables(db); err != nil {
+		return err
+	}
+	if err := initAlertTables(db); err != nil {
+		return err
+	}
+	if err := initNewsTables(db); err != nil {
+		return err
+	}
+	if err := initEventTables(db); err != nil {
+		return err
+	}
+
+	return nil
 }
 
 func (h *Handler) RegisterRoutes(app *fiber.App) {
+	// Initialize database tables
+	if err := h.Initialize(); err != nil {
+		log.Fatalf("[Stocks] Failed to initialize database: %v", err)
+	}
+
 	app.Get("/tickers", h.GetTickers)
+	app.Get("/tickers/status", h.GetStatus)
+
+	app.Get("/tickers/watchlists", h.GetWatchlists)
+	app.Post("/tickers/watchlists", h.CreateWatchlist)
+	app.Get("/tickers/watchlists/:name", h.GetWatchlist)
+	app.Delete("/tickers/watchlists/:name", h.DeleteWatchlist)
+	app.Post("/tickers/watchlists/:name/symbols", h.AddWatchlistSymbol)
+	app.Put("/tickers/watchlists/:name/symbols", h.ReorderWatchlistSymbols)
+	app.Delete("/tickers/watchlists/:name/symbols/:symbol", h.RemoveWatchlistSymbol)
+
+	app.Get("/tickers/alerts", h.GetAlerts)
+	app.Post("/tickers/alerts", h.CreateAlert)
+	app.Delete("/tickers/alerts/:id", h.DeleteAlert)
+	app.Post("/tickers/alerts/:id/test", h.TestAlert)
+
+	app.Get("/tickers/events", h.GetEvents)
+	app.Get("/tickers/events.ics", h.GetEventsCalendar)
+
+	app.Get("/tickers/:symbol/history", h.GetHistory)
+	app.Get("/tickers/:symbol/news", h.GetNews)
+	app.Get("/tickers/:symbol/indicators", h.GetIndicators)
+}
+
+// AddToJobScheduler adds periodic quote refreshing to the scheduler
+func (h *Handler) AddToJobScheduler(addJob func(string, time.Duration, func() error)) {
+	addJob("Tickers", cacheTime, h.RefreshQuotes)
+}
+
+// RefreshQuotes fetches every watchlist and alert symbol once, refreshes
+// the watchlist caches and evaluates alert rules against the new quotes
+func (h *Handler) RefreshQuotes() error {
+	watchlists, err := listWatchlists()
+	if err != nil {
+		return err
+	}
+	alertSymbols, err := alertSymbols()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	var symbols []string
+	addSymbol := func(symbol string) {
+		if !seen[symbol] {
+			seen[symbol] = true
+			symbols = append(symbols, symbol)
+		}
+	}
+	for _, watchlist := range watchlists {
+		for _, symbol := range watchlist.Symbols {
+			addSymbol(symbol)
+		}
+	}
+	for _, symbol := range alertSymbols {
+		addSymbol(symbol)
+	}
+	if len(symbols) == 0 {
+		return nil
+	}
+
+	// Requests compare against the stored benchmark quote, so it is
+	// fetched once per run rather than with every watchlist
+	if !seen[h.benchmark] {
+		if _, err := h.refreshBenchmark(); err != nil {
+			log.Printf("[Stocks] Failed to fetch benchmark %s: %v", h.benchmark, err)
+		}
+	}
+
+	tickerData, err := h.fetchTickers(symbols)
+	if err != nil {
+		return err
+	}
+
+	bySymbol := make(map[string]TickerData, len(tickerData))
+	for _, d := range tickerData {
+		bySymbol[d.Ticker] = d
+	}
+	for _, watchlist := range watchlists {
+		data := make([]TickerData, 0, len(watchlist.Symbols))
+		for _, symbol := range watchlist.Symbols {
+			if d, ok := bySymbol[symbol]; ok {
+				data = append(data, d)
+			}
+		}
+		updateCache(watchlist.Name, data)
+	}
+	log.Printf("[Stocks] Refreshed %d symbols across %d watchlists", len(tickerData), len(watchlists))
+
+	h.syncMoverNews(tickerData)
+
+	return h.evaluateAlerts(tickerData)
 }
 
+// GetTickers returns quotes for a watchlist in watchlist order, e.g.
+// /tickers?watchlist=tech. Without a watchlist the default one is used.
+// ?sort=change puts the biggest movers first and ?base=EUR converts prices
+// into another currency.
 func (h *Handler) GetTickers(c *fiber.Ctx) error {
-	if data, ok := getCachedData(); ok {
-		log.Printf("[Stocks] Returning data from cache")
-		sortTickersByDayChange(data)
+	name := c.Query("watchlist", DefaultWatchlist)
+
+	order := c.Query("sort", SortWatchlist)
+	if order != SortWatchlist && order != SortChange {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid sort %q, expected one of: watchlist, change", order),
+		})
+	}
+
+	base := strings.ToUpper(c.Query("base"))
+	if base != "" && !currencyRegex.MatchString(base) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid base currency %q, expected an ISO 4217 code such as EUR", base),
+		})
+	}
+
+	if data, ok := getCachedData(name); ok {
+		log.Printf("[Stocks] Returning data for watchlist %q from cache", name)
+		return h.respondTickers(c, data, base, order)
+	}
+
+	symbols := DefaultTickers
+	watchlist, err := getWatchlist(name)
+	switch {
+	case err == nil:
+		symbols = watchlist.Symbols
+	case errors.Is(err, errWatchlistNotFound) && name == DefaultWatchlist:
+		// Fall back to the built-in list if the default was deleted
+	default:
+		return watchlistError(c, name, err)
+	}
+
+	log.Printf("[Stocks] Cache miss for watchlist %q. Fetching data from API....", name)
+
+	tickerData, err := h.fetchTickers(symbols)
+	if err != nil {
+		log.Printf("[Stocks] Failed to fetch any ticker data: %v", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(tickerData) > 0 {
+		log.Printf("[Stocks] Successfully fetched data for %d tickers", len(tickerData))
+		updateCache(name, tickerData)
+
+		// Headlines show up once fetched; don't hold up the response
+		go h.syncMoverNews(slices.Clone(tickerData))
+	}
+
+	return h.respondTickers(c, tickerData, base, order)
+}
+
+// respondTickers writes ticker data with the current market session and
+// mover headlines, converted to base when one is given
+func (h *Handler) respondTickers(c *fiber.Ctx, data []TickerData, base, order string) error {
+	data = withHeadlines(withMarketSessions(data, time.Now()))
+	if order == SortChange {
+		data = sortedByDayChange(data)
+	}
+	if base == "" {
 		return c.JSON(data)
 	}
+	return c.JSON(h.convertTickers(data, base))
+}
+
+// Quotes returns ticker data for symbols, taken from the watchlist cache
+// when fresh and fetched otherwise, so other packages see the same quotes
+// as GET /tickers. Fetched symbols are cached as long as watchlists are, so
+// repeated calls don't go upstream each time. A non-empty base converts
+// prices into that currency.
+func (h *Handler) Quotes(symbols []string, base string) ([]TickerData, error) {
+	data := make([]TickerData, 0, len(symbols))
+	var missing []string
+	for _, symbol := range symbols {
+		if cached, ok := cachedQuote(symbol); ok {
+			data = append(data, cached)
+		} else {
+			missing = append(missing, symbol)
+		}
+	}
+
+	if len(missing) > 0 {
+		fetched, err := h.fetchTickers(missing)
+		if err != nil {
+			return nil, err
+		}
+		updateSymbolCache(fetched)
+		data = append(data, fetched...)
+	}
+
+	data = withMarketSessions(data, time.Now())
+	if base == "" {
+		return data, nil
+	}
+	return h.convertTickers(data, base), nil
+}
 
-	log.Printf("[Stocks] Cache miss. Fetching data from API....")
+// ValidateSymbol normalizes a symbol and checks that a provider knows it
+func (h *Handler) ValidateSymbol(symbol string) (string, error) {
+	return h.validateSymbol(symbol)
+}
 
-	tickerDataChan := make(chan *TickerData, len(DefaultTickers))
-	errors := make(chan error, len(DefaultTickers))
+// fetchTickers fetches all symbols concurrently. An error is only returned
+// when no symbol could be fetched.
+func (h *Handler) fetchTickers(symbols []string) ([]TickerData, error) {
+	tickerDataChan := make(chan *TickerData, len(symbols))
+	errChan := make(chan error, len(symbols))
 
 	var wg sync.WaitGroup
-	wg.Add(len(DefaultTickers))
+	wg.Add(len(symbols))
 
 	// Process each ticker
-	for _, ticker := range DefaultTickers {
+	for _, ticker := range symbols {
 		go func(t string) {
 			defer wg.Done()
-			data, err := fetchTickerData(t)
+			data, err := h.fetchTickerData(t)
 			if err != nil {
 				log.Printf("[Stocks] Error fetching %s: %v", t, err)
-				errors <- err
+
+				// Serve the last known quote rather than dropping the symbol
+				stored, ok, loadErr := loadStoredQuote(t)
+				if loadErr != nil {
+					log.Printf("[Stocks] Failed to load stored quote for %s: %v", t, loadErr)
+				}
+				if ok {
+					log.Printf("[Stocks] Serving stale quote for %s", t)
+					tickerDataChan <- &stored
+					return
+				}
+
+				errChan <- err
 				tickerDataChan <- nil
 				return
 			}
 
 			log.Printf("[Stocks] Successfully fetched data for %s", t)
+			if err := storeQuote(*data); err != nil {
+				log.Printf("[Stocks] Failed to store quote for %s: %v", t, err)
+			}
 			tickerDataChan <- data
 		}(ticker)
 	}
@@ -53,39 +320,150 @@ func (h *Handler) GetTickers(c *fiber.Ctx) error {
 	go func() {
 		wg.Wait()
 		close(tickerDataChan)
-		close(errors)
+		close(errChan)
 	}()
 
-	tickerData := make([]TickerData, 0, len(DefaultTickers))
+	tickerData := make([]TickerData, 0, len(symbols))
 	for data := range tickerDataChan {
 		if data != nil {
 			tickerData = append(tickerData, *data)
 		}
 	}
 
+	// Goroutines finish in any order; keep the order symbols were given in
+	position := make(map[string]int, len(symbols))
+	for i, symbol := range symbols {
+		position[symbol] = i
+	}
+	sort.SliceStable(tickerData, func(i, j int) bool {
+		return position[tickerData[i].Ticker] < position[tickerData[j].Ticker]
+	})
+
 	var errs []error
-	for err := range errors {
+	for err := range errChan {
 		if err != nil {
 			errs = append(errs, err)
 		}
 	}
 
 	if len(tickerData) == 0 && len(errs) > 0 {
-		log.Printf("[Stocks] Failed to fetch any ticker data: %v", errs[0])
-		return fiber.NewError(fiber.StatusInternalServerError, errs[0].Error())
+		return nil, errs[0]
 	}
 
-	if len(tickerData) > 0 {
-		log.Printf("[Stocks] Successfully fetched data for %d tickers", len(tickerData))
-		sortTickersByDayChange(tickerData)
-		updateCache(tickerData)
+	h.withRelativePerformance(tickerData)
+	return tickerData, nil
+}
+
+// fetchTickerData refreshes the stored daily series for a symbol and
+// computes every window and range metric from it
+func (h *Handler) fetchTickerData(ticker string) (*TickerData, error) {
+	// A week of slack keeps a bar from before the one-year mark
+	from := time.Now().AddDate(-1, 0, -7)
+
+	// Always refresh the tail here; the ticker cache already limits how often
+	series, err := h.syncHistory(ticker, IntervalDay, from, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	points, err := storedHistory(ticker, IntervalDay, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 {
+		return nil, fmt.Errorf("no data returned for ticker %s", ticker)
+	}
+
+	// Providers without a live quote report today's price as the last bar
+	var quote *Quote
+	if series != nil {
+		quote = series.Quote
+	}
+	if quote == nil {
+		last := points[len(points)-1]
+		quote = &Quote{
+			Symbol:     ticker,
+			Price:      last.Close,
+			MarketTime: time.Unix(last.Time, 0).UTC(),
+		}
+		if series != nil {
+			quote.Currency = series.Currency
+			quote.Provider = series.Provider
+		}
 	}
 
-	return c.JSON(tickerData)
+	currency := quote.Currency
+	if currency == "" {
+		currency = inferCurrency(ticker)
+	}
+
+	currentPrice := quote.Price
+	changes := computeChanges(currentPrice, quote.MarketTime, points, 0)
+	metrics := computeRangeMetrics(quote, points)
+	sma200 := sma(points, 200)
+
+	data := &TickerData{
+		Ticker:           ticker,
+		Currency:         currency,
+		TodaysPrice:      &currentPrice,
+		DayChange:        changes.Day,
+		WeekChange:       changes.Week,
+		MonthChange:      changes.Month,
+		YTDChange:        changes.YTD,
+		YearChange:       changes.Year,
+		DayHigh:          metrics.DayHigh,
+		DayLow:           metrics.DayLow,
+		Volume:           metrics.Volume,
+		AvgVolume:        metrics.AvgVolume,
+		FiftyTwoWeekHigh: metrics.FiftyTwoWeekHigh,
+		FiftyTwoWeekLow:  metrics.FiftyTwoWeekLow,
+		PercentFromHigh:  metrics.PercentFromHigh,
+		SMA200:           sma200,
+		AboveSMA200:      above(currentPrice, sma200),
+		Provider:         quote.Provider,
+		AsOf:             marketTimePtr(quote.MarketTime),
+	}
+	h.withExtendedHours(data)
+	return data, nil
+}
+
+// withExtendedHours fills in pre- and post-market prices. They are only
+// requested outside regular hours, the one time they add to the quote.
+func (h *Handler) withExtendedHours(data *TickerData) {
+	state := marketSession(data.Ticker, time.Now()).State
+	if state != MarketPre && state != MarketPost {
+		return
+	}
+	provider, ok := h.provider.(ExtendedHoursProvider)
+	if !ok {
+		return
+	}
+	prices, err := provider.ExtendedHours(data.Ticker)
+	if err != nil {
+		log.Printf("[Stocks] Failed to fetch extended hours for %s: %v", data.Ticker, err)
+		return
+	}
+	data.PreMarketPrice = extendedHoursPrice(prices.Pre)
+	data.PostMarketPrice = extendedHoursPrice(prices.Post)
+}
+
+// extendedHoursPrice is nil when the provider reported no price
+func extendedHoursPrice(price float64) *float64 {
+	if price <= 0 {
+		return nil
+	}
+	return &price
+}
+
+// GetStatus reports the rate limiter's request budget and per-host state
+func (h *Handler) GetStatus(c *fiber.Ctx) error {
+	return c.JSON(limiter.status())
 }
 
-func sortTickersByDayChange(data []TickerData) {
-	sort.Slice(data, func(i, j int) bool {
+// sortedByDayChange returns a copy of data with the biggest movers first
+func sortedByDayChange(data []TickerData) []TickerData {
+	data = slices.Clone(data)
+	sort.SliceStable(data, func(i, j int) bool {
 		if data[i].DayChange == nil {
 			return false
 		}
@@ -96,6 +474,7 @@ func sortTickersByDayChange(data []TickerData) {
 		// This will show biggest movers (both up and down) first
 		return abs(*data[i].DayChange) > abs(*data[j].DayChange)
 	})
+	return data
 }
 
 func abs(x float64) float64 {
@@ -0,0 +1,288 @@
+package tickers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// historyMaxAge is how long stored bars are trusted before the tail is refreshed
+	historyMaxAge = 15 * time.Minute
+
+	// historyTailOverlap re-fetches recent bars, which may have been partial
+	// (today's bar, the current week) when they were stored
+	historyTailOverlap = 7 * 24 * time.Hour
+)
+
+// historyRanges maps the ?range= values to how far back they reach
+var historyRanges = map[string]func(time.Time) time.Time{
+	"1mo": func(t time.Time) time.Time { return t.AddDate(0, -1, 0) },
+	"6mo": func(t time.Time) time.Time { return t.AddDate(0, -6, 0) },
+	"1y":  func(t time.Time) time.Time { return t.AddDate(-1, 0, 0) },
+	"5y":  func(t time.Time) time.Time { return t.AddDate(-5, 0, 0) },
+}
+
+// HistoryResponse is returned by GET /tickers/:symbol/history
+type HistoryResponse struct {
+	Symbol   string       `json:"symbol"`
+	Range    string       `json:"range"`
+	Interval string       `json:"interval"`
+	Points   []PricePoint `json:"points"`
+}
+
+// initHistoryTables creates the tables backing the stored price series
+func initHistoryTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_prices (
+			symbol TEXT NOT NULL,
+			interval TEXT NOT NULL,
+			time INTEGER NOT NULL,
+			open REAL,
+			high REAL,
+			low REAL,
+			close REAL NOT NULL,
+			volume INTEGER,
+			PRIMARY KEY (symbol, interval, time)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Tracks how far back each series has been requested and when it was
+	// last refreshed, so only missing spans are fetched
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_price_sync (
+			symbol TEXT NOT NULL,
+			interval TEXT NOT NULL,
+			covered_from INTEGER NOT NULL,
+			fetched_at TIMESTAMP NOT NULL,
+			PRIMARY KEY (symbol, interval)
+		)
+	`)
+	return err
+}
+
+// syncHistory makes sure the stored series for symbol reaches back to from
+// and is no older than maxAge. Only the missing head and the recent tail are
+// requested from the provider. The most recently fetched series is returned
+// for its provider metadata and quote, or nil if nothing was fetched.
+func (h *Handler) syncHistory(symbol, interval string, from time.Time, maxAge time.Duration) (*Series, error) {
+	db := database.GetDB()
+	now := time.Now()
+
+	var coveredFrom int64
+	var fetchedAt time.Time
+	err := db.QueryRow(`
+		SELECT covered_from, fetched_at
+		FROM ticker_price_sync
+		WHERE symbol = ? AND interval = ?
+	`, symbol, interval).Scan(&coveredFrom, &fetchedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		series, err := h.provider.History(symbol, from, now, interval)
+		if err != nil {
+			return nil, err
+		}
+		if err := storeHistory(symbol, interval, from, series.Points); err != nil {
+			return nil, err
+		}
+		return series, updateSync(symbol, interval, from.Unix(), now)
+	}
+
+	// Extend the series backwards if this request reaches further than before
+	if from.Unix() < coveredFrom {
+		series, err := h.provider.History(symbol, from, time.Unix(coveredFrom, 0), interval)
+		if err != nil {
+			return nil, err
+		}
+		if err := storeHistory(symbol, interval, time.Time{}, series.Points); err != nil {
+			return nil, err
+		}
+		coveredFrom = from.Unix()
+		if err := updateSync(symbol, interval, coveredFrom, fetchedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if now.Sub(fetchedAt) < maxAge {
+		return nil, nil
+	}
+
+	// Refresh the tail, starting a little before the last stored bar
+	var lastTime sql.NullInt64
+	err = db.QueryRow(`
+		SELECT MAX(time) FROM ticker_prices WHERE symbol = ? AND interval = ?
+	`, symbol, interval).Scan(&lastTime)
+	if err != nil {
+		return nil, err
+	}
+	tailFrom := time.Unix(coveredFrom, 0)
+	if lastTime.Valid {
+		tailFrom = time.Unix(lastTime.Int64, 0).Add(-historyTailOverlap)
+	}
+
+	series, err := h.provider.History(symbol, tailFrom, now, interval)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeHistory(symbol, interval, tailFrom, series.Points); err != nil {
+		return nil, err
+	}
+	return series, updateSync(symbol, interval, coveredFrom, now)
+}
+
+// storeHistory saves bars. When replaceFrom is set, stored bars from that
+// time on are dropped first so partial bars don't linger next to their
+// final version. A stored bar for the same day or week as a new one is
+// replaced too, as providers stamp the same bar with different times.
+func storeHistory(symbol, interval string, replaceFrom time.Time, points []PricePoint) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !replaceFrom.IsZero() && len(points) > 0 {
+		_, err := tx.Exec(`
+			DELETE FROM ticker_prices WHERE symbol = ? AND interval = ? AND time >= ?
+		`, symbol, interval, replaceFrom.Unix())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, p := range points {
+		start, end := barPeriod(interval, p.Time)
+		_, err := tx.Exec(`
+			DELETE FROM ticker_prices WHERE symbol = ? AND interval = ? AND time >= ? AND time < ?
+		`, symbol, interval, start, end)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT OR REPLACE INTO ticker_prices
+			(symbol, interval, time, open, high, low, close, volume)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`, symbol, interval, p.Time, p.Open, p.High, p.Low, p.Close, p.Volume)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// barPeriod returns the Unix bounds [start, end) of the exchange day, or
+// for weekly bars the Monday-to-Monday week, that a bar at t belongs to
+func barPeriod(interval string, t int64) (start, end int64) {
+	local := time.Unix(t, 0).In(tradingDayLocation)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, tradingDayLocation)
+	if interval != IntervalWeek {
+		return day.Unix(), day.AddDate(0, 0, 1).Unix()
+	}
+	monday := day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	return monday.Unix(), monday.AddDate(0, 0, 7).Unix()
+}
+
+func updateSync(symbol, interval string, coveredFrom int64, fetchedAt time.Time) error {
+	_, err := database.GetDB().Exec(`
+		INSERT OR REPLACE INTO ticker_price_sync (symbol, interval, covered_from, fetched_at)
+		VALUES (?, ?, ?, ?)
+	`, symbol, interval, coveredFrom, fetchedAt.UTC())
+	return err
+}
+
+// storedHistory reads bars at or after from, oldest first
+func storedHistory(symbol, interval string, from time.Time) ([]PricePoint, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT time, open, high, low, close, volume
+		FROM ticker_prices
+		WHERE symbol = ? AND interval = ? AND time >= ?
+		ORDER BY time ASC
+	`, symbol, interval, from.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := make([]PricePoint, 0)
+	for rows.Next() {
+		var p PricePoint
+		if err := rows.Scan(&p.Time, &p.Open, &p.High, &p.Low, &p.Close, &p.Volume); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+
+	return points, rows.Err()
+}
+
+// loadHistory syncs and returns the stored series. When the provider fails
+// but bars were stored earlier, those are returned instead of an error.
+func (h *Handler) loadHistory(symbol, interval string, from time.Time) ([]PricePoint, error) {
+	_, syncErr := h.syncHistory(symbol, interval, from, historyMaxAge)
+	if syncErr != nil {
+		log.Printf("[Stocks] Failed to sync %s history for %s: %v", interval, symbol, syncErr)
+	}
+
+	points, err := storedHistory(symbol, interval, from)
+	if err != nil {
+		return nil, err
+	}
+	if len(points) == 0 && syncErr != nil {
+		return nil, syncErr
+	}
+	return points, nil
+}
+
+// GetHistory returns OHLCV bars, e.g. /tickers/SPY/history?range=6mo&interval=1wk
+func (h *Handler) GetHistory(c *fiber.Ctx) error {
+	symbol, err := normalizeSymbol(symbolParam(c, "symbol"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	rangeParam := c.Query("range", "1y")
+	start, ok := historyRanges[rangeParam]
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid range %q, expected one of: 1mo, 6mo, 1y, 5y", rangeParam),
+		})
+	}
+
+	interval := c.Query("interval", IntervalDay)
+	if interval != IntervalDay && interval != IntervalWeek {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid interval %q, expected 1d or 1wk", interval),
+		})
+	}
+
+	points, err := h.loadHistory(symbol, interval, start(time.Now()))
+	if errors.Is(err, ErrSymbolNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to fetch history: %v", err),
+		})
+	}
+
+	return c.JSON(HistoryResponse{
+		Symbol:   symbol,
+		Range:    rangeParam,
+		Interval: interval,
+		Points:   points,
+	})
+}
@@ -0,0 +1,266 @@
+package tickers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// DefaultBenchmark is compared against unless TICKER_BENCHMARK is set
+	DefaultBenchmark = "SPY"
+
+	rsiPeriod = 14
+
+	// indicatorLookback reaches far enough back for SMA200 and a one-year
+	// drawdown, allowing for weekends and holidays
+	indicatorLookback = 400 * 24 * time.Hour
+)
+
+// Indicators is returned by GET /tickers/:symbol/indicators
+type Indicators struct {
+	Symbol string     `json:"symbol"`
+	Price  float64    `json:"price"`
+	AsOf   *time.Time `json:"asOf"`
+	SMA20  *float64   `json:"sma20"`
+	SMA50  *float64   `json:"sma50"`
+	SMA200 *float64   `json:"sma200"`
+	RSI14  *float64   `json:"rsi14"`
+	// Drawdown is the percent below the highest close of the past year
+	Drawdown    *float64             `json:"drawdown"`
+	AboveSMA50  *bool                `json:"aboveSma50"`
+	AboveSMA200 *bool                `json:"aboveSma200"`
+	Relative    *RelativePerformance `json:"relative"`
+}
+
+// RelativePerformance is a symbol's change minus the benchmark's change
+// over each window, in percentage points
+type RelativePerformance struct {
+	Benchmark string   `json:"benchmark"`
+	Day       *float64 `json:"day"`
+	Week      *float64 `json:"week"`
+	Month     *float64 `json:"month"`
+	YTD       *float64 `json:"ytd"`
+	Year      *float64 `json:"year"`
+}
+
+// benchmarkFromEnv reads TICKER_BENCHMARK, defaulting to SPY
+func benchmarkFromEnv() string {
+	if value := os.Getenv("TICKER_BENCHMARK"); value != "" {
+		if symbol, err := normalizeSymbol(value); err == nil {
+			return symbol
+		}
+		log.Printf("[Stocks] Ignoring invalid TICKER_BENCHMARK %q", value)
+	}
+	return DefaultBenchmark
+}
+
+// sma is the mean close of the last period bars
+func sma(points []PricePoint, period int) *float64 {
+	if len(points) < period {
+		return nil
+	}
+	var total float64
+	for _, p := range points[len(points)-period:] {
+		total += p.Close
+	}
+	return floatPtr(total / float64(period))
+}
+
+// rsi uses Wilder's smoothing over the whole series
+func rsi(points []PricePoint, period int) *float64 {
+	if len(points) <= period {
+		return nil
+	}
+
+	var gain, loss float64
+	for i := 1; i <= period; i++ {
+		change := points[i].Close - points[i-1].Close
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+
+	for i := period + 1; i < len(points); i++ {
+		change := points[i].Close - points[i-1].Close
+		up, down := 0.0, 0.0
+		if change > 0 {
+			up = change
+		} else {
+			down = -change
+		}
+		gain = (gain*float64(period-1) + up) / float64(period)
+		loss = (loss*float64(period-1) + down) / float64(period)
+	}
+
+	if loss == 0 {
+		return floatPtr(100)
+	}
+	return floatPtr(100 - 100/(1+gain/loss))
+}
+
+// drawdown is how far price sits below the highest close since from
+func drawdown(points []PricePoint, price float64, from time.Time) *float64 {
+	peak := price
+	for _, p := range points {
+		if p.Time >= from.Unix() && p.Close > peak {
+			peak = p.Close
+		}
+	}
+	return percentChange(price, peak)
+}
+
+func above(price float64, average *float64) *bool {
+	if average == nil {
+		return nil
+	}
+	result := price > *average
+	return &result
+}
+
+// relativePerformance subtracts the benchmark's changes from the symbol's
+func relativePerformance(benchmark string, symbol, base priceChanges) *RelativePerformance {
+	diff := func(a, b *float64) *float64 {
+		if a == nil || b == nil {
+			return nil
+		}
+		return floatPtr(*a - *b)
+	}
+	return &RelativePerformance{
+		Benchmark: benchmark,
+		Day:       diff(symbol.Day, base.Day),
+		Week:      diff(symbol.Week, base.Week),
+		Month:     diff(symbol.Month, base.Month),
+		YTD:       diff(symbol.YTD, base.YTD),
+		Year:      diff(symbol.Year, base.Year),
+	}
+}
+
+// changesOf reads the change windows back out of ticker data
+func changesOf(d TickerData) priceChanges {
+	return priceChanges{
+		Day:   d.DayChange,
+		Week:  d.WeekChange,
+		Month: d.MonthChange,
+		YTD:   d.YTDChange,
+		Year:  d.YearChange,
+	}
+}
+
+// withRelativePerformance compares every symbol in data to the benchmark.
+// When the benchmark isn't part of data its stored quote is used, which
+// RefreshQuotes keeps current; it is only fetched here if never stored.
+func (h *Handler) withRelativePerformance(data []TickerData) {
+	var benchmark *TickerData
+	for i := range data {
+		if data[i].Ticker == h.benchmark {
+			benchmark = &data[i]
+		}
+	}
+	if benchmark == nil {
+		stored, ok, err := loadStoredQuote(h.benchmark)
+		if err != nil {
+			log.Printf("[Stocks] Failed to load stored benchmark %s: %v", h.benchmark, err)
+		}
+		if !ok {
+			fetched, err := h.refreshBenchmark()
+			if err != nil {
+				log.Printf("[Stocks] Failed to fetch benchmark %s: %v", h.benchmark, err)
+				return
+			}
+			stored = *fetched
+		}
+		benchmark = &stored
+	}
+
+	base := changesOf(*benchmark)
+	for i := range data {
+		if data[i].Ticker != h.benchmark {
+			data[i].Relative = relativePerformance(h.benchmark, changesOf(data[i]), base)
+		}
+	}
+}
+
+// refreshBenchmark fetches the benchmark and stores its quote
+func (h *Handler) refreshBenchmark() (*TickerData, error) {
+	data, err := h.fetchTickerData(h.benchmark)
+	if err != nil {
+		return nil, err
+	}
+	if err := storeQuote(*data); err != nil {
+		log.Printf("[Stocks] Failed to store quote for %s: %v", h.benchmark, err)
+	}
+	return data, nil
+}
+
+// seriesChanges computes the change windows of a stored daily series,
+// taking its last close as the current price
+func seriesChanges(points []PricePoint) (price float64, asOf time.Time, changes priceChanges) {
+	last := points[len(points)-1]
+	asOf = time.Unix(last.Time, 0).UTC()
+	return last.Close, asOf, computeChanges(last.Close, asOf, points, 0)
+}
+
+// GetIndicators returns moving averages, RSI, drawdown and performance
+// relative to the benchmark, e.g. /tickers/QQQ/indicators
+func (h *Handler) GetIndicators(c *fiber.Ctx) error {
+	symbol, err := normalizeSymbol(symbolParam(c, "symbol"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	benchmark, err := normalizeSymbol(c.Query("benchmark", h.benchmark))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	from := time.Now().Add(-indicatorLookback)
+	points, err := h.loadHistory(symbol, IntervalDay, from)
+	if errors.Is(err, ErrSymbolNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to fetch history: %v", err),
+		})
+	}
+	if len(points) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("No history for %s", symbol),
+		})
+	}
+
+	price, asOf, changes := seriesChanges(points)
+	indicators := Indicators{
+		Symbol:   symbol,
+		Price:    price,
+		AsOf:     &asOf,
+		SMA20:    sma(points, 20),
+		SMA50:    sma(points, 50),
+		SMA200:   sma(points, 200),
+		RSI14:    rsi(points, rsiPeriod),
+		Drawdown: drawdown(points, price, asOf.AddDate(-1, 0, 0)),
+	}
+	indicators.AboveSMA50 = above(price, indicators.SMA50)
+	indicators.AboveSMA200 = above(price, indicators.SMA200)
+
+	if benchmark != symbol {
+		benchmarkPoints, err := h.loadHistory(benchmark, IntervalDay, from)
+		if err != nil {
+			log.Printf("[Stocks] Failed to load benchmark %s: %v", benchmark, err)
+		} else if len(benchmarkPoints) > 0 {
+			_, _, benchmarkChanges := seriesChanges(benchmarkPoints)
+			indicators.Relative = relativePerformance(benchmark, changes, benchmarkChanges)
+		}
+	}
+
+	return c.JSON(indicators)
+}
@@ -0,0 +1,297 @@
+package tickers
+
+import (
+	"strings"
+	"time"
+	_ "time/tzdata" // The runtime image ships without a zoneinfo database
+)
+
+// Market states reported with each quote
+const (
+	MarketPre     = "pre"
+	MarketRegular = "regular"
+	MarketPost    = "post"
+	MarketClosed  = "closed"
+)
+
+// MarketSession describes where a symbol's market is in its trading day
+type MarketSession struct {
+	State string
+	// NextOpen and NextClose are nil for markets that never close
+	NextOpen  *time.Time
+	NextClose *time.Time
+}
+
+// MustLoadLocation loads an IANA timezone, panicking if it is unknown. It
+// is meant for package-level variables with fixed names.
+func MustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
+
+// exchangeCalendar holds an exchange's session times as minutes after
+// local midnight. Exchanges without extended hours use the regular
+// open and close for the pre and post bounds.
+type exchangeCalendar struct {
+	location   *time.Location
+	preOpen    int
+	open       int
+	close      int
+	earlyClose int
+	postClose  int
+	// holidays returns closed dates and early-close dates for a year.
+	// Only the US calendar models holidays; other exchanges just skip weekends.
+	holidays func(year int) (closed, early map[string]bool)
+}
+
+var nyseCalendar = &exchangeCalendar{
+	location:   MustLoadLocation("America/New_York"),
+	preOpen:    4 * 60,
+	open:       9*60 + 30,
+	close:      16 * 60,
+	earlyClose: 13 * 60,
+	postClose:  20 * 60,
+	holidays:   nyseHolidays,
+}
+
+// exchangeSuffixCalendars maps Yahoo exchange suffixes to their calendar
+var exchangeSuffixCalendars = map[string]*exchangeCalendar{
+	".L":  regularHours("Europe/London", 8*60, 16*60+30),
+	".DE": regularHours("Europe/Berlin", 9*60, 17*60+30),
+	".F":  regularHours("Europe/Berlin", 8*60, 22*60),
+	".PA": regularHours("Europe/Paris", 9*60, 17*60+30),
+	".AS": regularHours("Europe/Amsterdam", 9*60, 17*60+30),
+	".MI": regularHours("Europe/Rome", 9*60, 17*60+30),
+	".MC": regularHours("Europe/Madrid", 9*60, 17*60+30),
+	".SW": regularHours("Europe/Zurich", 9*60, 17*60+30),
+	".TO": regularHours("America/Toronto", 9*60+30, 16*60),
+	".V":  regularHours("America/Toronto", 9*60+30, 16*60),
+	".T":  regularHours("Asia/Tokyo", 9*60, 15*60+30),
+	".HK": regularHours("Asia/Hong_Kong", 9*60+30, 16*60),
+	".AX": regularHours("Australia/Sydney", 10*60, 16*60),
+	".NS": regularHours("Asia/Kolkata", 9*60+15, 15*60+30),
+	".BO": regularHours("Asia/Kolkata", 9*60+15, 15*60+30),
+}
+
+func regularHours(location string, open, close int) *exchangeCalendar {
+	return &exchangeCalendar{
+		location:   MustLoadLocation(location),
+		preOpen:    open,
+		open:       open,
+		close:      close,
+		earlyClose: close,
+		postClose:  close,
+	}
+}
+
+// fxSessionHour is when the 24/5 FX week opens on Sunday and closes on
+// Friday, in New York time
+const fxSessionHour = 17
+
+// marketSession returns the session state of symbol's market at now
+func marketSession(symbol string, now time.Time) MarketSession {
+	symbol = strings.ToUpper(symbol)
+	switch {
+	case strings.HasSuffix(symbol, "=X"):
+		return fxSession(now)
+	case isCryptoPair(symbol):
+		// Crypto pairs trade around the clock
+		return MarketSession{State: MarketRegular}
+	}
+	if dot := strings.LastIndex(symbol, "."); dot > 0 {
+		if calendar, ok := exchangeSuffixCalendars[symbol[dot:]]; ok {
+			return calendar.session(now)
+		}
+	}
+	return nyseCalendar.session(now)
+}
+
+// fxSession treats FX as one session per week, open from Sunday evening
+// to Friday evening in New York
+func fxSession(now time.Time) MarketSession {
+	local := now.In(nyseCalendar.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, local.Location())
+	weekStart := midnight.AddDate(0, 0, -int(local.Weekday()))
+
+	open := time.Date(weekStart.Year(), weekStart.Month(), weekStart.Day(), fxSessionHour, 0, 0, 0, local.Location())
+	close := open.AddDate(0, 0, 5)
+
+	if local.Before(open) {
+		// Sunday before the open; the week closed on Friday
+		return MarketSession{State: MarketClosed, NextOpen: &open, NextClose: &close}
+	}
+	if local.Before(close) {
+		nextOpen := open.AddDate(0, 0, 7)
+		return MarketSession{State: MarketRegular, NextOpen: &nextOpen, NextClose: &close}
+	}
+	nextOpen := open.AddDate(0, 0, 7)
+	nextClose := close.AddDate(0, 0, 7)
+	return MarketSession{State: MarketClosed, NextOpen: &nextOpen, NextClose: &nextClose}
+}
+
+// tradingDay reports whether date is a session and when it closes
+func (c *exchangeCalendar) tradingDay(date time.Time) (bool, int) {
+	if date.Weekday() == time.Saturday || date.Weekday() == time.Sunday {
+		return false, 0
+	}
+	if c.holidays != nil {
+		closed, early := c.holidays(date.Year())
+		key := date.Format("2006-01-02")
+		if closed[key] {
+			return false, 0
+		}
+		if early[key] {
+			return true, c.earlyClose
+		}
+	}
+	return true, c.close
+}
+
+func (c *exchangeCalendar) session(now time.Time) MarketSession {
+	local := now.In(c.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.location)
+	at := func(day time.Time, minutes int) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(), minutes/60, minutes%60, 0, 0, c.location)
+	}
+
+	state := MarketClosed
+	var nextOpen, nextClose *time.Time
+
+	if open, closeMinutes := c.tradingDay(midnight); open {
+		// Extended hours end early on half days too
+		postClose := closeMinutes + (c.postClose - c.close)
+		switch {
+		case local.Before(at(midnight, c.preOpen)):
+		case local.Before(at(midnight, c.open)):
+			state = MarketPre
+		case local.Before(at(midnight, closeMinutes)):
+			state = MarketRegular
+		case local.Before(at(midnight, postClose)):
+			state = MarketPost
+		}
+
+		if local.Before(at(midnight, c.open)) {
+			openTime, closeTime := at(midnight, c.open), at(midnight, closeMinutes)
+			nextOpen, nextClose = &openTime, &closeTime
+		} else if local.Before(at(midnight, closeMinutes)) {
+			closeTime := at(midnight, closeMinutes)
+			nextClose = &closeTime
+		}
+	}
+
+	// Find the next session, two weeks covers any run of holidays
+	for day := midnight.AddDate(0, 0, 1); nextOpen == nil && day.Before(midnight.AddDate(0, 0, 14)); day = day.AddDate(0, 0, 1) {
+		if open, closeMinutes := c.tradingDay(day); open {
+			openTime := at(day, c.open)
+			nextOpen = &openTime
+			if nextClose == nil {
+				closeTime := at(day, closeMinutes)
+				nextClose = &closeTime
+			}
+		}
+	}
+
+	return MarketSession{State: state, NextOpen: nextOpen, NextClose: nextClose}
+}
+
+// nyseHolidays returns the NYSE's full closures and 1pm early closes
+func nyseHolidays(year int) (closed, early map[string]bool) {
+	closed = make(map[string]bool)
+	early = make(map[string]bool)
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	add := func(t time.Time) {
+		closed[t.Format("2006-01-02")] = true
+	}
+
+	// Saturday holidays move to Friday and Sunday ones to Monday, except
+	// that New Year's Day is not observed on the previous year's last day
+	if newYear := date(time.January, 1); newYear.Weekday() != time.Saturday {
+		add(observed(newYear))
+	}
+	add(nthWeekday(year, time.January, time.Monday, 3))  // Martin Luther King Jr. Day
+	add(nthWeekday(year, time.February, time.Monday, 3)) // Washington's Birthday
+	add(easter(year).AddDate(0, 0, -2))                  // Good Friday
+	add(lastWeekday(year, time.May, time.Monday))        // Memorial Day
+	if year >= 2022 {
+		add(observed(date(time.June, 19))) // Juneteenth
+	}
+	add(observed(date(time.July, 4)))
+	add(nthWeekday(year, time.September, time.Monday, 1)) // Labor Day
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving)
+	add(observed(date(time.December, 25)))
+
+	for _, day := range []time.Time{
+		date(time.July, 3),
+		thanksgiving.AddDate(0, 0, 1),
+		date(time.December, 24),
+	} {
+		key := day.Format("2006-01-02")
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday && !closed[key] {
+			early[key] = true
+		}
+	}
+
+	return closed, early
+}
+
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+// nthWeekday returns the nth weekday of a month, e.g. the 4th Thursday
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+func lastWeekday(year int, month time.Month, weekday time.Weekday) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC)
+	offset := (int(last.Weekday()) - int(weekday) + 7) % 7
+	return last.AddDate(0, 0, -offset)
+}
+
+// easter computes Easter Sunday with the anonymous Gregorian algorithm
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
+
+// withMarketSessions returns copies of data with the current market
+// session filled in, so cached quotes still report the live state
+func withMarketSessions(data []TickerData, now time.Time) []TickerData {
+	result := make([]TickerData, len(data))
+	for i, d := range data {
+		session := marketSession(d.Ticker, now)
+		d.MarketState = session.State
+		d.NextOpen = session.NextOpen
+		d.NextClose = session.NextClose
+		result[i] = d
+	}
+	return result
+}
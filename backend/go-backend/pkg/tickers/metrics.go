@@ -0,0 +1,99 @@
+package tickers
+
+import "time"
+
+// averageVolumeDays is the number of completed sessions averaged for avgVolume
+const averageVolumeDays = 30
+
+// rangeMetrics are the non-change figures derived from a daily series
+type rangeMetrics struct {
+	DayHigh          *float64
+	DayLow           *float64
+	Volume           *int64
+	AvgVolume        *int64
+	FiftyTwoWeekHigh *float64
+	FiftyTwoWeekLow  *float64
+	PercentFromHigh  *float64
+}
+
+// computeRangeMetrics derives intraday range, volume and the 52-week range.
+// The quote's own intraday figures win when the provider reports them;
+// otherwise they come from today's bar.
+func computeRangeMetrics(quote *Quote, points []PricePoint) rangeMetrics {
+	var metrics rangeMetrics
+	if len(points) == 0 {
+		return metrics
+	}
+
+	loc := quote.MarketTime.Location()
+	today := civilDate(quote.MarketTime)
+
+	// Split off today's bar so averages only use completed sessions
+	completed := points
+	var todayBar *PricePoint
+	if last := points[len(points)-1]; civilDate(time.Unix(last.Time, 0).In(loc)).Equal(today) {
+		todayBar = &last
+		completed = points[:len(points)-1]
+	}
+
+	switch {
+	case quote.DayHigh > 0 && quote.DayLow > 0:
+		metrics.DayHigh = floatPtr(quote.DayHigh)
+		metrics.DayLow = floatPtr(quote.DayLow)
+	case todayBar != nil:
+		metrics.DayHigh = floatPtr(todayBar.High)
+		metrics.DayLow = floatPtr(todayBar.Low)
+	}
+
+	switch {
+	case quote.Volume > 0:
+		metrics.Volume = int64Ptr(quote.Volume)
+	case todayBar != nil:
+		metrics.Volume = int64Ptr(todayBar.Volume)
+	}
+
+	if n := min(len(completed), averageVolumeDays); n > 0 {
+		var total int64
+		for _, p := range completed[len(completed)-n:] {
+			total += p.Volume
+		}
+		metrics.AvgVolume = int64Ptr(total / int64(n))
+	}
+
+	yearAgo := today.AddDate(-1, 0, 0)
+	high, low := quote.Price, quote.Price
+	for _, p := range points {
+		if civilDate(time.Unix(p.Time, 0).In(loc)).Before(yearAgo) {
+			continue
+		}
+		// Some bars lack high/low; fall back to the close
+		barHigh, barLow := p.High, p.Low
+		if barHigh == 0 {
+			barHigh = p.Close
+		}
+		if barLow == 0 {
+			barLow = p.Close
+		}
+		if barHigh > high {
+			high = barHigh
+		}
+		if barLow < low {
+			low = barLow
+		}
+	}
+	if high > 0 {
+		metrics.FiftyTwoWeekHigh = floatPtr(high)
+		metrics.FiftyTwoWeekLow = floatPtr(low)
+		metrics.PercentFromHigh = percentChange(quote.Price, high)
+	}
+
+	return metrics
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
@@ -0,0 +1,270 @@
+package tickers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-backend/pkg/database"
+	"go-backend/pkg/rss"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// newsMaxAge is how long stored headlines are served before refetching
+	newsMaxAge = 30 * time.Minute
+
+	// Movers get a headline when their day change is at least this large
+	moverCount     = 3
+	moverThreshold = 1.0 // percent
+)
+
+// DefaultNewsFeeds is used unless TICKER_NEWS_FEEDS is set
+const DefaultNewsFeeds = "yahoo=https://feeds.finance.yahoo.com/rss/2.0/headline?s={symbol}&region=US&lang=en-US"
+
+// FeedFetcher fetches the entries of an RSS feed
+type FeedFetcher interface {
+	FetchRSSFeed(url string) ([]rss.FeedEntry, error)
+}
+
+// newsFeed is a per-symbol headline feed; {symbol} in template is replaced
+type newsFeed struct {
+	source   string
+	template string
+}
+
+// Headline is a news item about a symbol
+type Headline struct {
+	Symbol      string     `json:"symbol"`
+	Source      string     `json:"source"`
+	Title       string     `json:"title"`
+	Link        string     `json:"link"`
+	PublishedAt *time.Time `json:"publishedAt"`
+}
+
+// newsFeedsFromEnv reads TICKER_NEWS_FEEDS, a comma-separated list of
+// source=template pairs, e.g.
+// "yahoo=https://feeds.finance.yahoo.com/rss/2.0/headline?s={symbol}"
+func newsFeedsFromEnv() []newsFeed {
+	value := os.Getenv("TICKER_NEWS_FEEDS")
+	if value == "" {
+		value = DefaultNewsFeeds
+	}
+
+	var feeds []newsFeed
+	for _, pair := range strings.Split(value, ",") {
+		source, template, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || source == "" || !strings.Contains(template, "{symbol}") {
+			log.Printf("[Stocks] Ignoring invalid news feed %q in TICKER_NEWS_FEEDS", pair)
+			continue
+		}
+		feeds = append(feeds, newsFeed{source: source, template: template})
+	}
+	return feeds
+}
+
+func (f newsFeed) url(symbol string) string {
+	return strings.ReplaceAll(f.template, "{symbol}", url.QueryEscape(symbol))
+}
+
+// initNewsTables creates the per-symbol headline tables
+func initNewsTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_news (
+			symbol TEXT NOT NULL,
+			source TEXT NOT NULL,
+			title TEXT NOT NULL,
+			link TEXT NOT NULL,
+			published_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (symbol, link)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_news_sync (
+			symbol TEXT PRIMARY KEY,
+			fetched_at TIMESTAMP NOT NULL
+		)
+	`)
+	return err
+}
+
+// syncNews refetches a symbol's feeds when the stored headlines are older
+// than newsMaxAge. One failing feed does not stop the others.
+func (h *Handler) syncNews(symbol string) error {
+	db := database.GetDB()
+
+	var fetchedAt time.Time
+	err := db.QueryRow(`SELECT fetched_at FROM ticker_news_sync WHERE symbol = ?`, symbol).Scan(&fetchedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if err == nil && time.Since(fetchedAt) < newsMaxAge {
+		return nil
+	}
+
+	var errs []error
+	for _, feed := range h.newsFeeds {
+		entries, err := h.feeds.FetchRSSFeed(feed.url(symbol))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", feed.source, err))
+			continue
+		}
+		if err := storeHeadlines(symbol, feed.source, entries); err != nil {
+			return err
+		}
+	}
+	if len(errs) == len(h.newsFeeds) && len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	_, err = db.Exec(`
+		INSERT OR REPLACE INTO ticker_news_sync (symbol, fetched_at) VALUES (?, ?)
+	`, symbol, time.Now().UTC())
+	return err
+}
+
+func storeHeadlines(symbol, source string, entries []rss.FeedEntry) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		title, link := strings.TrimSpace(entry.Title), strings.TrimSpace(entry.Link)
+		if title == "" || link == "" {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT OR REPLACE INTO ticker_news (symbol, source, title, link, published_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, symbol, source, title, link, entry.Published)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// storedHeadlines returns a symbol's newest headlines first
+func storedHeadlines(symbol string, limit int) ([]Headline, error) {
+	rows, err := database.GetDB().Query(`
+		SELECT symbol, source, title, link, published_at
+		FROM ticker_news
+		WHERE symbol = ?
+		ORDER BY COALESCE(published_at, created_at) DESC
+		LIMIT ?
+	`, symbol, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	headlines := make([]Headline, 0)
+	for rows.Next() {
+		var headline Headline
+		var publishedAt sql.NullTime
+		if err := rows.Scan(&headline.Symbol, &headline.Source, &headline.Title, &headline.Link, &publishedAt); err != nil {
+			return nil, err
+		}
+		if publishedAt.Valid {
+			headline.PublishedAt = &publishedAt.Time
+		}
+		headlines = append(headlines, headline)
+	}
+	return headlines, rows.Err()
+}
+
+// topMovers returns the symbols with the largest day moves above the threshold
+func topMovers(data []TickerData) []string {
+	var movers []string
+	for _, d := range sortedByDayChange(data) {
+		if d.DayChange != nil && abs(*d.DayChange) >= moverThreshold {
+			movers = append(movers, d.Ticker)
+		}
+	}
+	if len(movers) > moverCount {
+		movers = movers[:moverCount]
+	}
+	return movers
+}
+
+// syncMoverNews fetches headlines for the biggest movers in data
+func (h *Handler) syncMoverNews(data []TickerData) {
+	for _, symbol := range topMovers(data) {
+		if err := h.syncNews(symbol); err != nil {
+			log.Printf("[Stocks] Failed to fetch news for %s: %v", symbol, err)
+		}
+	}
+}
+
+// withHeadlines returns copies of data where the biggest movers carry their
+// latest stored headline
+func withHeadlines(data []TickerData) []TickerData {
+	movers := make(map[string]bool)
+	for _, symbol := range topMovers(data) {
+		movers[symbol] = true
+	}
+
+	result := make([]TickerData, len(data))
+	for i, d := range data {
+		d.TopHeadline = nil
+		if movers[d.Ticker] {
+			headlines, err := storedHeadlines(d.Ticker, 1)
+			if err != nil {
+				log.Printf("[Stocks] Failed to load headline for %s: %v", d.Ticker, err)
+			} else if len(headlines) > 0 {
+				d.TopHeadline = &headlines[0]
+			}
+		}
+		result[i] = d
+	}
+	return result
+}
+
+// GetNews returns recent headlines for a symbol, e.g. /tickers/SPY/news?limit=10
+func (h *Handler) GetNews(c *fiber.Ctx) error {
+	symbol, err := normalizeSymbol(symbolParam(c, "symbol"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid limit, expected a number between 1 and 100",
+		})
+	}
+
+	syncErr := h.syncNews(symbol)
+	if syncErr != nil {
+		log.Printf("[Stocks] Failed to fetch news for %s: %v", symbol, syncErr)
+	}
+
+	headlines, err := storedHeadlines(symbol, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to load news: %v", err),
+		})
+	}
+	if len(headlines) == 0 && syncErr != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": fmt.Sprintf("Failed to fetch news: %v", syncErr),
+		})
+	}
+
+	return c.JSON(headlines)
+}
@@ -0,0 +1,106 @@
+package tickers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Supported notifier types for alerts
+const (
+	NotifierWebhook = "webhook"
+	NotifierNtfy    = "ntfy"
+)
+
+// Notification is what an alert delivers when it fires
+type Notification struct {
+	Title   string     `json:"title"`
+	Message string     `json:"message"`
+	Alert   Alert      `json:"alert"`
+	Ticker  TickerData `json:"ticker"`
+}
+
+// Notifier delivers a fired alert somewhere
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// WebhookNotifier POSTs the notification as JSON to a URL
+type WebhookNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookNotifier(url string, client *http.Client) *WebhookNotifier {
+	return &WebhookNotifier{url: url, client: client}
+}
+
+func (w *WebhookNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return sendNotification(w.client, req)
+}
+
+// NtfyNotifier POSTs a plain-text message to an ntfy topic URL, e.g.
+// https://ntfy.sh/my-alerts, with the title in a header
+type NtfyNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func NewNtfyNotifier(url string, client *http.Client) *NtfyNotifier {
+	return &NtfyNotifier{url: url, client: client}
+}
+
+func (n *NtfyNotifier) Notify(notification Notification) error {
+	req, err := http.NewRequest("POST", n.url, strings.NewReader(notification.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("Title", notification.Title)
+	req.Header.Set("Tags", "chart_with_upwards_trend")
+
+	return sendNotification(n.client, req)
+}
+
+func sendNotification(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("notification target returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// newNotifier builds the notifier for an alert's type and target URL
+func newNotifier(kind, target string, client *http.Client) (Notifier, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid notifier target %q, expected an http(s) URL", target)
+	}
+
+	switch kind {
+	case NotifierWebhook:
+		return NewWebhookNotifier(target, client), nil
+	case NotifierNtfy:
+		return NewNtfyNotifier(target, client), nil
+	}
+	return nil, fmt.Errorf("unknown notifier %q, expected %s or %s", kind, NotifierWebhook, NotifierNtfy)
+}
@@ -0,0 +1,209 @@
+package tickers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// ErrSymbolNotFound is returned by providers that do not know a symbol
+var ErrSymbolNotFound = errors.New("symbol not found")
+
+// Provider is a source of market data
+type Provider interface {
+	// Name identifies the provider in responses and logs
+	Name() string
+	// Quote returns the latest quote for a symbol
+	Quote(symbol string) (*Quote, error)
+	// History returns bars between from and to at the given interval ("1d" or "1wk")
+	History(symbol string, from, to time.Time, interval string) (*Series, error)
+}
+
+// EventProvider is implemented by providers that report corporate events
+type EventProvider interface {
+	// Events returns dividends, splits and earnings dates between from and to
+	Events(symbol string, from, to time.Time) ([]Event, error)
+}
+
+// ExtendedHoursProvider is implemented by providers that report pre- and
+// post-market trading
+type ExtendedHoursProvider interface {
+	// ExtendedHours returns the latest extended-hours prices of the
+	// current or last trading day
+	ExtendedHours(symbol string) (*ExtendedHoursPrices, error)
+}
+
+// ExtendedHoursPrices are the last pre- and post-market trades, zero when
+// there were none
+type ExtendedHoursPrices struct {
+	Pre  float64
+	Post float64
+}
+
+// Quote is the latest price of a symbol as reported by a provider
+type Quote struct {
+	Symbol        string
+	Price         float64
+	PreviousClose float64
+	Currency      string
+	// MarketTime is in the exchange's timezone so dates match trading days
+	MarketTime time.Time
+	DayHigh    float64
+	DayLow     float64
+	Volume     int64
+	Provider   string
+}
+
+// Series is a run of bars for one symbol
+type Series struct {
+	Symbol   string
+	Currency string
+	Interval string
+	Points   []PricePoint
+	// Quote is set when the provider returns the latest quote alongside
+	// the bars, which saves a separate request
+	Quote    *Quote
+	Provider string
+}
+
+// Supported history intervals
+const (
+	IntervalDay  = "1d"
+	IntervalWeek = "1wk"
+)
+
+// failoverProvider tries each provider in order until one succeeds
+type failoverProvider struct {
+	providers []Provider
+}
+
+func newFailoverProvider(providers ...Provider) *failoverProvider {
+	return &failoverProvider{providers: providers}
+}
+
+func (f *failoverProvider) Name() string {
+	names := make([]string, len(f.providers))
+	for i, p := range f.providers {
+		names[i] = p.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (f *failoverProvider) Quote(symbol string) (*Quote, error) {
+	var errs []error
+	for _, p := range f.providers {
+		quote, err := p.Quote(symbol)
+		if err == nil {
+			quote.Provider = p.Name()
+			return quote, nil
+		}
+		log.Printf("[Stocks] Provider %s failed to quote %s: %v", p.Name(), symbol, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, joinProviderErrors(symbol, errs)
+}
+
+func (f *failoverProvider) History(symbol string, from, to time.Time, interval string) (*Series, error) {
+	var errs []error
+	for _, p := range f.providers {
+		series, err := p.History(symbol, from, to, interval)
+		if err == nil {
+			series.Provider = p.Name()
+			if series.Quote != nil {
+				series.Quote.Provider = p.Name()
+			}
+			return series, nil
+		}
+		log.Printf("[Stocks] Provider %s failed to fetch %s history for %s: %v", p.Name(), interval, symbol, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	return nil, joinProviderErrors(symbol, errs)
+}
+
+// Events asks each provider that supports events in turn
+func (f *failoverProvider) Events(symbol string, from, to time.Time) ([]Event, error) {
+	var errs []error
+	for _, p := range f.providers {
+		events, ok := p.(EventProvider)
+		if !ok {
+			continue
+		}
+		result, err := events.Events(symbol, from, to)
+		if err == nil {
+			return result, nil
+		}
+		log.Printf("[Stocks] Provider %s failed to fetch events for %s: %v", p.Name(), symbol, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no configured provider supports corporate events")
+	}
+	return nil, joinProviderErrors(symbol, errs)
+}
+
+// ExtendedHours asks each provider that reports extended hours in turn
+func (f *failoverProvider) ExtendedHours(symbol string) (*ExtendedHoursPrices, error) {
+	var errs []error
+	for _, p := range f.providers {
+		extended, ok := p.(ExtendedHoursProvider)
+		if !ok {
+			continue
+		}
+		prices, err := extended.ExtendedHours(symbol)
+		if err == nil {
+			return prices, nil
+		}
+		log.Printf("[Stocks] Provider %s failed to fetch extended hours for %s: %v", p.Name(), symbol, err)
+		errs = append(errs, fmt.Errorf("%s: %w", p.Name(), err))
+	}
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no configured provider supports extended hours")
+	}
+	return nil, joinProviderErrors(symbol, errs)
+}
+
+// joinProviderErrors combines the errors of every provider. The result only
+// matches ErrSymbolNotFound when every provider reported it, so a transient
+// failure in one provider is not mistaken for an unknown symbol.
+func joinProviderErrors(symbol string, errs []error) error {
+	if len(errs) == 0 {
+		return fmt.Errorf("no market data providers configured")
+	}
+	for _, err := range errs {
+		if !errors.Is(err, ErrSymbolNotFound) {
+			return fmt.Errorf("all providers failed for %s: %v", symbol, errors.Join(errs...))
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+}
+
+// newProvidersFromEnv builds the provider chain. TICKER_PROVIDERS sets the
+// failover order (default "yahoo,stooq"); YAHOO_BASE_URL and STOOQ_BASE_URL
+// override the endpoints, e.g. to point at a local test server.
+func newProvidersFromEnv(client *http.Client) *failoverProvider {
+	order := os.Getenv("TICKER_PROVIDERS")
+	if order == "" {
+		order = "yahoo,stooq"
+	}
+
+	var providers []Provider
+	for _, name := range strings.Split(order, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "yahoo":
+			providers = append(providers, NewYahooProvider(os.Getenv("YAHOO_BASE_URL"), client))
+		case "stooq":
+			providers = append(providers, NewStooqProvider(os.Getenv("STOOQ_BASE_URL"), client))
+		case "":
+		default:
+			log.Printf("[Stocks] Ignoring unknown provider %q in TICKER_PROVIDERS", name)
+		}
+	}
+
+	provider := newFailoverProvider(providers...)
+	log.Printf("[Stocks] Using market data providers: %s", provider.Name())
+	return provider
+}
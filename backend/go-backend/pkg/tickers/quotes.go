@@ -0,0 +1,71 @@
+package tickers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"go-backend/pkg/database"
+)
+
+// initQuoteTables creates the table holding the last good quote per symbol
+func initQuoteTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_quotes (
+			symbol TEXT PRIMARY KEY,
+			data JSON NOT NULL,
+			as_of TIMESTAMP,  -- Market time of the quote
+			updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	return err
+}
+
+// storeQuote saves the latest successfully fetched quote for a symbol
+func storeQuote(data TickerData) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	var asOf interface{}
+	if data.AsOf != nil {
+		asOf = data.AsOf.UTC()
+	}
+
+	_, err = database.GetDB().Exec(`
+		INSERT OR REPLACE INTO ticker_quotes (symbol, data, as_of, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	`, data.Ticker, encoded, asOf)
+	return err
+}
+
+// loadStoredQuote returns the last stored quote for a symbol, flagged as
+// stale. ok is false when nothing was ever stored.
+func loadStoredQuote(symbol string) (data TickerData, ok bool, err error) {
+	var encoded []byte
+	err = database.GetDB().QueryRow(`
+		SELECT data FROM ticker_quotes WHERE symbol = ?
+	`, symbol).Scan(&encoded)
+	if errors.Is(err, sql.ErrNoRows) {
+		return TickerData{}, false, nil
+	}
+	if err != nil {
+		return TickerData{}, false, err
+	}
+
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return TickerData{}, false, err
+	}
+	data.Stale = true
+	return data, true, nil
+}
+
+// marketTimePtr returns nil for the zero time so "asOf" is null when unknown
+func marketTimePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,285 @@
+package tickers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRateLimited is returned while a host is backing off after 429s
+	ErrRateLimited = errors.New("rate limited")
+	// ErrTooManyRequests is returned, along with ErrRateLimited, when the
+	// host itself answered 429 rather than the limiter holding back
+	ErrTooManyRequests = errors.New("too many requests")
+	// ErrBudgetExhausted is returned when the package-wide request budget is spent
+	ErrBudgetExhausted = errors.New("request budget exhausted")
+)
+
+const (
+	// Backoff after the first 429, doubled for each consecutive one
+	baseBackoff = 30 * time.Second
+	maxBackoff  = 30 * time.Minute
+
+	// Consecutive 429s before the circuit opens and only probes get through
+	breakerThreshold = 3
+
+	defaultRequestBudget = 60 // requests per minute
+)
+
+// Circuit breaker states
+const (
+	breakerClosed   = "closed"
+	breakerBackoff  = "backoff"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// hostBreaker tracks rate limiting for one upstream host
+type hostBreaker struct {
+	consecutive429s int
+	retryAt         time.Time
+	lastRetryAfter  time.Duration
+	probing         bool
+	requests        int
+	rateLimited     int
+}
+
+func (b *hostBreaker) state(now time.Time) string {
+	switch {
+	case b.consecutive429s == 0:
+		return breakerClosed
+	case now.Before(b.retryAt) && b.consecutive429s >= breakerThreshold:
+		return breakerOpen
+	case now.Before(b.retryAt):
+		return breakerBackoff
+	}
+	return breakerHalfOpen
+}
+
+// requestBudget is a token bucket refilled continuously at limit per minute
+type requestBudget struct {
+	limit    int
+	tokens   float64
+	refilled time.Time
+}
+
+func (b *requestBudget) refill(now time.Time) {
+	elapsed := now.Sub(b.refilled).Minutes()
+	b.tokens = math.Min(float64(b.limit), b.tokens+elapsed*float64(b.limit))
+	b.refilled = now
+}
+
+// rateLimiter guards every outgoing market-data request
+type rateLimiter struct {
+	mu     sync.Mutex
+	hosts  map[string]*hostBreaker
+	budget requestBudget
+}
+
+func newRateLimiter(budgetPerMinute int) *rateLimiter {
+	return &rateLimiter{
+		hosts: make(map[string]*hostBreaker),
+		budget: requestBudget{
+			limit:    budgetPerMinute,
+			tokens:   float64(budgetPerMinute),
+			refilled: time.Now(),
+		},
+	}
+}
+
+// limiter is shared by all providers so the budget covers the whole package
+var limiter = newRateLimiter(requestBudgetFromEnv())
+
+// requestBudgetFromEnv reads TICKER_REQUEST_BUDGET (requests per minute)
+func requestBudgetFromEnv() int {
+	if value := os.Getenv("TICKER_REQUEST_BUDGET"); value != "" {
+		if budget, err := strconv.Atoi(value); err == nil && budget > 0 {
+			return budget
+		}
+		log.Printf("[Stocks] Ignoring invalid TICKER_REQUEST_BUDGET %q", value)
+	}
+	return defaultRequestBudget
+}
+
+// acquire reserves a request to host, failing fast when the host is
+// backing off or the budget is spent. While the circuit is half-open only
+// one probe request is let through.
+func (l *rateLimiter) acquire(host string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	breaker := l.breaker(host)
+
+	switch breaker.state(now) {
+	case breakerBackoff, breakerOpen:
+		return fmt.Errorf("%w: %s until %s", ErrRateLimited, host, breaker.retryAt.Format(time.RFC3339))
+	case breakerHalfOpen:
+		if breaker.probing {
+			return fmt.Errorf("%w: %s is being probed", ErrRateLimited, host)
+		}
+		breaker.probing = true
+	}
+
+	l.budget.refill(now)
+	if l.budget.tokens < 1 {
+		breaker.probing = false
+		return ErrBudgetExhausted
+	}
+	l.budget.tokens--
+	breaker.requests++
+
+	return nil
+}
+
+// record updates the host's breaker with the outcome of a request
+func (l *rateLimiter) record(host string, resp *http.Response) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	breaker := l.breaker(host)
+	breaker.probing = false
+
+	if resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		if resp != nil && breaker.consecutive429s > 0 {
+			log.Printf("[Stocks] %s recovered after %d rate-limited requests", host, breaker.consecutive429s)
+			breaker.consecutive429s = 0
+		}
+		return
+	}
+
+	breaker.consecutive429s++
+	breaker.rateLimited++
+
+	backoff := baseBackoff * time.Duration(1<<min(breaker.consecutive429s-1, 10))
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	breaker.lastRetryAfter = retryAfter
+	if retryAfter > backoff {
+		backoff = retryAfter
+	}
+	breaker.retryAt = time.Now().Add(backoff)
+
+	log.Printf("[Stocks] Rate limited by %s (%d in a row), backing off for %v", host, breaker.consecutive429s, backoff)
+	if breaker.consecutive429s == breakerThreshold {
+		log.Printf("[Stocks] Circuit open for %s", host)
+	}
+
+	// Keep serving cached data while the host is unavailable
+	ExtendCacheTime(backoff)
+}
+
+func (l *rateLimiter) breaker(host string) *hostBreaker {
+	breaker, ok := l.hosts[host]
+	if !ok {
+		breaker = &hostBreaker{}
+		l.hosts[host] = breaker
+	}
+	return breaker
+}
+
+// do sends a request through the limiter. A 429 response is turned into
+// ErrRateLimited so callers can fail over to another provider.
+func (l *rateLimiter) do(client *http.Client, req *http.Request) (*http.Response, error) {
+	host := req.URL.Host
+	if err := l.acquire(host); err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	l.record(host, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %w from %s", ErrRateLimited, ErrTooManyRequests, host)
+	}
+
+	return resp, nil
+}
+
+// parseRetryAfter accepts both forms of the header: delay in seconds or an HTTP date
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// HostStatus describes the rate-limit state of one upstream host
+type HostStatus struct {
+	Host            string     `json:"host"`
+	State           string     `json:"state"`
+	Consecutive429s int        `json:"consecutive429s"`
+	RetryAt         *time.Time `json:"retryAt"`
+	LastRetryAfter  float64    `json:"lastRetryAfterSeconds"`
+	Requests        int        `json:"requests"`
+	RateLimited     int        `json:"rateLimited"`
+}
+
+// BudgetStatus describes the package-wide request budget
+type BudgetStatus struct {
+	LimitPerMinute int `json:"limitPerMinute"`
+	Remaining      int `json:"remaining"`
+}
+
+// LimiterStatus is served by GET /tickers/status
+type LimiterStatus struct {
+	Budget BudgetStatus `json:"budget"`
+	Hosts  []HostStatus `json:"hosts"`
+}
+
+func (l *rateLimiter) status() LimiterStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	l.budget.refill(now)
+
+	status := LimiterStatus{
+		Budget: BudgetStatus{
+			LimitPerMinute: l.budget.limit,
+			Remaining:      int(l.budget.tokens),
+		},
+		Hosts: make([]HostStatus, 0, len(l.hosts)),
+	}
+
+	for host, breaker := range l.hosts {
+		hostStatus := HostStatus{
+			Host:            host,
+			State:           breaker.state(now),
+			Consecutive429s: breaker.consecutive429s,
+			LastRetryAfter:  breaker.lastRetryAfter.Seconds(),
+			Requests:        breaker.requests,
+			RateLimited:     breaker.rateLimited,
+		}
+		if now.Before(breaker.retryAt) {
+			retryAt := breaker.retryAt
+			hostStatus.RetryAt = &retryAt
+		}
+		status.Hosts = append(status.Hosts, hostStatus)
+	}
+	sort.Slice(status.Hosts, func(i, j int) bool {
+		return status.Hosts[i].Host < status.Hosts[j].Host
+	})
+
+	return status
+}
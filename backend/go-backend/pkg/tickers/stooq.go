@@ -0,0 +1,222 @@
+package tickers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStooqBaseURL is Stooq's public CSV download host
+const DefaultStooqBaseURL = "https://stooq.com"
+
+// Stooq quotes are timestamped in Warsaw time
+var stooqLocation = MustLoadLocation("Europe/Warsaw")
+
+// StooqProvider fetches market data from Stooq's CSV endpoints. Stooq
+// does not report currency, so quotes assume the listing's usual one.
+type StooqProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewStooqProvider creates a Stooq provider. An empty baseURL uses
+// DefaultStooqBaseURL.
+func NewStooqProvider(baseURL string, client *http.Client) *StooqProvider {
+	if baseURL == "" {
+		baseURL = DefaultStooqBaseURL
+	}
+	return &StooqProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client:  client,
+	}
+}
+
+func (s *StooqProvider) Name() string {
+	return "stooq"
+}
+
+// stooqSymbol maps Yahoo-style symbols to Stooq's naming:
+// SPY -> spy.us, EURUSD=X -> eurusd, BTC-USD -> btcusd, VOD.L -> vod.uk,
+// BRK-B -> brk-b.us
+func stooqSymbol(symbol string) string {
+	crypto := isCryptoPair(symbol)
+	symbol = strings.ToLower(symbol)
+	switch {
+	case strings.HasSuffix(symbol, "=x"):
+		return strings.TrimSuffix(symbol, "=x")
+	case crypto:
+		return strings.ReplaceAll(symbol, "-", "")
+	case strings.HasPrefix(symbol, "^"):
+		return symbol
+	case strings.HasSuffix(symbol, ".l"):
+		return strings.TrimSuffix(symbol, ".l") + ".uk"
+	case strings.Contains(symbol, "."):
+		return symbol
+	}
+	return symbol + ".us"
+}
+
+// Quote fetches the latest quote from Stooq's snapshot CSV
+func (s *StooqProvider) Quote(symbol string) (*Quote, error) {
+	query := url.Values{}
+	query.Set("s", stooqSymbol(symbol))
+	query.Set("f", "sd2t2ohlcv")
+	query.Set("h", "")
+	query.Set("e", "csv")
+
+	records, err := s.fetchCSV("/q/l/?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 || len(records[1]) < 8 {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+
+	// Symbol,Date,Time,Open,High,Low,Close,Volume
+	row := records[1]
+	if row[1] == "N/D" {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+
+	marketTime, err := time.ParseInLocation("2006-01-02 15:04:05", row[1]+" "+row[2], stooqLocation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse quote time %q: %v", row[1]+" "+row[2], err)
+	}
+	values, err := parseFloats(row[3:7])
+	if err != nil {
+		return nil, err
+	}
+	volume, _ := strconv.ParseFloat(row[7], 64)
+
+	return &Quote{
+		Symbol:     symbol,
+		Price:      values[3],
+		MarketTime: marketTime,
+		DayHigh:    values[1],
+		DayLow:     values[2],
+		Volume:     int64(volume),
+	}, nil
+}
+
+// History fetches daily or weekly bars from Stooq's history CSV
+func (s *StooqProvider) History(symbol string, from, to time.Time, interval string) (*Series, error) {
+	var stooqInterval string
+	switch interval {
+	case IntervalDay:
+		stooqInterval = "d"
+	case IntervalWeek:
+		stooqInterval = "w"
+	default:
+		return nil, fmt.Errorf("unsupported interval %q", interval)
+	}
+
+	query := url.Values{}
+	query.Set("s", stooqSymbol(symbol))
+	query.Set("d1", from.Format("20060102"))
+	query.Set("d2", to.Format("20060102"))
+	query.Set("i", stooqInterval)
+
+	records, err := s.fetchCSV("/q/d/l/?" + query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+
+	// Date,Open,High,Low,Close,Volume
+	points := make([]PricePoint, 0, len(records)-1)
+	for _, row := range records[1:] {
+		if len(row) < 5 {
+			continue
+		}
+		date, err := time.Parse("2006-01-02", row[0])
+		if err != nil {
+			continue
+		}
+		values, err := parseFloats(row[1:5])
+		if err != nil {
+			continue
+		}
+		var volume float64
+		if len(row) > 5 {
+			volume, _ = strconv.ParseFloat(row[5], 64)
+		}
+		points = append(points, PricePoint{
+			// Bars only carry a date; noon UTC keeps that date intact in
+			// any exchange timezone
+			Time:   date.Add(12 * time.Hour).Unix(),
+			Open:   values[0],
+			High:   values[1],
+			Low:    values[2],
+			Close:  values[3],
+			Volume: int64(volume),
+		})
+	}
+
+	if len(points) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+	}
+
+	return &Series{
+		Symbol:   symbol,
+		Interval: interval,
+		Points:   points,
+	}, nil
+}
+
+func (s *StooqProvider) fetchCSV(path string) ([][]string, error) {
+	requestURL := s.baseURL + path
+	log.Printf("[Stocks] Requesting URL: %s", requestURL)
+
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	resp, err := limiter.do(s.client, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %v", err)
+	}
+
+	// Unknown symbols get a plain "No data" body instead of CSV
+	if strings.HasPrefix(strings.TrimSpace(string(body)), "No data") {
+		return nil, ErrSymbolNotFound
+	}
+
+	reader := csv.NewReader(strings.NewReader(string(body)))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CSV: %v", err)
+	}
+	return records, nil
+}
+
+func parseFloats(fields []string) ([]float64, error) {
+	values := make([]float64, len(fields))
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q", field)
+		}
+		values[i] = value
+	}
+	return values, nil
+}
@@ -1,11 +1,64 @@
 package tickers
 
+import "time"
+
 type TickerData struct {
 	Ticker      string   `json:"ticker"`
+	Currency    string   `json:"currency"`
 	TodaysPrice *float64 `json:"todaysPrice"`
 	DayChange   *float64 `json:"dayChange"`
 	WeekChange  *float64 `json:"weekChange"`
+	MonthChange *float64 `json:"monthChange"`
+	YTDChange   *float64 `json:"ytdChange"`
 	YearChange  *float64 `json:"yearChange"`
+
+	DayHigh          *float64 `json:"dayHigh"`
+	DayLow           *float64 `json:"dayLow"`
+	Volume           *int64   `json:"volume"`
+	AvgVolume        *int64   `json:"avgVolume"`
+	FiftyTwoWeekHigh *float64 `json:"fiftyTwoWeekHigh"`
+	FiftyTwoWeekLow  *float64 `json:"fiftyTwoWeekLow"`
+	// PercentFromHigh is how far the price sits below the 52-week high
+	PercentFromHigh *float64 `json:"percentFromHigh"`
+
+	SMA200      *float64 `json:"sma200"`
+	AboveSMA200 *bool    `json:"aboveSma200"`
+	// Relative compares each change window against the benchmark
+	Relative *RelativePerformance `json:"relative,omitempty"`
+
+	// PreMarketPrice and PostMarketPrice are set when the provider reports
+	// extended-hours trading
+	PreMarketPrice  *float64 `json:"preMarketPrice,omitempty"`
+	PostMarketPrice *float64 `json:"postMarketPrice,omitempty"`
+
+	// MarketState is pre, regular, post or closed at the time of the response
+	MarketState string     `json:"marketState"`
+	NextOpen    *time.Time `json:"nextOpen"`
+	NextClose   *time.Time `json:"nextClose"`
+
+	// TopHeadline is the latest news item for the biggest movers
+	TopHeadline *Headline `json:"topHeadline,omitempty"`
+
+	Provider string `json:"provider"`
+	// FXRate is set when prices were converted with ?base=
+	FXRate *float64 `json:"fxRate,omitempty"`
+	// Unconverted is set when ?base= was asked for but the exchange rate
+	// could not be fetched, so prices stay in Currency
+	Unconverted bool `json:"unconverted,omitempty"`
+	// AsOf is the market time of the quote
+	AsOf *time.Time `json:"asOf"`
+	// Stale is set when every provider failed and the last stored quote is served
+	Stale bool `json:"stale"`
+}
+
+// PricePoint is a single OHLCV bar
+type PricePoint struct {
+	Time   int64   `json:"time"`
+	Open   float64 `json:"open"`
+	High   float64 `json:"high"`
+	Low    float64 `json:"low"`
+	Close  float64 `json:"close"`
+	Volume int64   `json:"volume"`
 }
 
 var DefaultTickers = []string{
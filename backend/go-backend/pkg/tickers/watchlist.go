@@ -0,0 +1,475 @@
+package tickers
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go-backend/pkg/database"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DefaultWatchlist is served by GET /tickers when no watchlist is given
+const DefaultWatchlist = "default"
+
+var (
+	errWatchlistNotFound = errors.New("watchlist not found")
+	ErrInvalidSymbol     = errors.New("invalid symbol")
+
+	watchlistNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+	symbolRegex        = regexp.MustCompile(`^[A-Z0-9^][A-Z0-9.=^-]{0,19}$`)
+)
+
+// Watchlist is a named, ordered list of symbols
+type Watchlist struct {
+	Name    string   `json:"name"`
+	Symbols []string `json:"symbols"`
+}
+
+// initWatchlistTables creates the watchlist tables and seeds the default
+// watchlist on first run
+func initWatchlistTables(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_watchlists (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS ticker_watchlist_symbols (
+			watchlist_id INTEGER NOT NULL,
+			symbol TEXT NOT NULL,
+			position INTEGER NOT NULL,
+			PRIMARY KEY (watchlist_id, symbol)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ticker_watchlists`).Scan(&count); err != nil {
+		return err
+	}
+	if count == 0 {
+		log.Printf("[Stocks] Seeding %q watchlist with %d default tickers", DefaultWatchlist, len(DefaultTickers))
+		if err := createWatchlist(DefaultWatchlist, DefaultTickers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// normalizeSymbol upper-cases a symbol and checks it looks like a ticker
+func normalizeSymbol(symbol string) (string, error) {
+	symbol = strings.ToUpper(strings.TrimSpace(symbol))
+	if !symbolRegex.MatchString(symbol) {
+		return "", fmt.Errorf("%w %q", ErrInvalidSymbol, symbol)
+	}
+	return symbol, nil
+}
+
+// validateSymbol normalizes a symbol and confirms a provider knows it
+func (h *Handler) validateSymbol(symbol string) (string, error) {
+	symbol, err := normalizeSymbol(symbol)
+	if err != nil {
+		return "", err
+	}
+	if _, err := h.provider.Quote(symbol); err != nil {
+		return "", err
+	}
+	return symbol, nil
+}
+
+// symbolError responds to a failed validateSymbol. Unknown or malformed
+// symbols are the client's fault; provider outages are not.
+func symbolError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, ErrInvalidSymbol) || errors.Is(err, ErrSymbolNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+		"error": fmt.Sprintf("Could not validate symbol: %v", err),
+	})
+}
+
+// symbolParam reads a symbol from the route, undoing URL escaping of
+// characters such as ^ in index symbols
+func symbolParam(c *fiber.Ctx, key string) string {
+	value := c.Params(key)
+	if unescaped, err := url.PathUnescape(value); err == nil {
+		return unescaped
+	}
+	return value
+}
+
+func getWatchlist(name string) (*Watchlist, error) {
+	db := database.GetDB()
+
+	var id int64
+	err := db.QueryRow(`SELECT id FROM ticker_watchlists WHERE name = ?`, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, errWatchlistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT symbol
+		FROM ticker_watchlist_symbols
+		WHERE watchlist_id = ?
+		ORDER BY position ASC
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	watchlist := &Watchlist{Name: name, Symbols: make([]string, 0)}
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, err
+		}
+		watchlist.Symbols = append(watchlist.Symbols, symbol)
+	}
+
+	return watchlist, rows.Err()
+}
+
+func listWatchlists() ([]Watchlist, error) {
+	db := database.GetDB()
+
+	rows, err := db.Query(`SELECT name FROM ticker_watchlists ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+
+	watchlists := make([]Watchlist, 0, len(names))
+	for _, name := range names {
+		watchlist, err := getWatchlist(name)
+		if err != nil {
+			return nil, err
+		}
+		watchlists = append(watchlists, *watchlist)
+	}
+
+	return watchlists, nil
+}
+
+func createWatchlist(name string, symbols []string) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO ticker_watchlists (name) VALUES (?)`, name)
+	if err != nil {
+		return err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	if err := writeSymbols(tx, id, symbols); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setWatchlistSymbols replaces a watchlist's symbols with the given order
+func setWatchlistSymbols(name string, symbols []string) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM ticker_watchlists WHERE name = ?`, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errWatchlistNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM ticker_watchlist_symbols WHERE watchlist_id = ?`, id); err != nil {
+		return err
+	}
+	if err := writeSymbols(tx, id, symbols); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func writeSymbols(tx *sql.Tx, watchlistID int64, symbols []string) error {
+	for position, symbol := range symbols {
+		_, err := tx.Exec(`
+			INSERT OR IGNORE INTO ticker_watchlist_symbols (watchlist_id, symbol, position)
+			VALUES (?, ?, ?)
+		`, watchlistID, symbol, position)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deleteWatchlist(name string) error {
+	tx, err := database.GetDB().Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM ticker_watchlists WHERE name = ?`, name).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errWatchlistNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM ticker_watchlist_symbols WHERE watchlist_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM ticker_watchlists WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// watchlistError maps storage errors to an HTTP response
+func watchlistError(c *fiber.Ctx, name string, err error) error {
+	if errors.Is(err, errWatchlistNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Watchlist %q not found", name),
+		})
+	}
+	log.Printf("[Stocks] Watchlist %q operation failed: %v", name, err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": fmt.Sprintf("Watchlist operation failed: %v", err),
+	})
+}
+
+// GetWatchlists lists all watchlists with their symbols
+func (h *Handler) GetWatchlists(c *fiber.Ctx) error {
+	watchlists, err := listWatchlists()
+	if err != nil {
+		return watchlistError(c, "", err)
+	}
+	return c.JSON(watchlists)
+}
+
+// GetWatchlist returns a single watchlist
+func (h *Handler) GetWatchlist(c *fiber.Ctx) error {
+	name := c.Params("name")
+	watchlist, err := getWatchlist(name)
+	if err != nil {
+		return watchlistError(c, name, err)
+	}
+	return c.JSON(watchlist)
+}
+
+// CreateWatchlist creates a watchlist from {"name": "tech", "symbols": [...]}.
+// Every symbol is validated against the provider before saving.
+func (h *Handler) CreateWatchlist(c *fiber.Ctx) error {
+	var body Watchlist
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	body.Name = strings.ToLower(strings.TrimSpace(body.Name))
+	if !watchlistNameRegex.MatchString(body.Name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Watchlist name must be 1-32 lowercase letters, digits, '-' or '_'",
+		})
+	}
+
+	if _, err := getWatchlist(body.Name); err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("Watchlist %q already exists", body.Name),
+		})
+	} else if !errors.Is(err, errWatchlistNotFound) {
+		return watchlistError(c, body.Name, err)
+	}
+
+	symbols := make([]string, 0, len(body.Symbols))
+	for _, raw := range body.Symbols {
+		symbol, err := h.validateSymbol(raw)
+		if err != nil {
+			return symbolError(c, err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	if err := createWatchlist(body.Name, symbols); err != nil {
+		return watchlistError(c, body.Name, err)
+	}
+
+	log.Printf("[Stocks] Created watchlist %q with %d symbols", body.Name, len(symbols))
+	watchlist, err := getWatchlist(body.Name)
+	if err != nil {
+		return watchlistError(c, body.Name, err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(watchlist)
+}
+
+// DeleteWatchlist removes a watchlist and its symbols
+func (h *Handler) DeleteWatchlist(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if err := deleteWatchlist(name); err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	invalidateCache(name)
+	log.Printf("[Stocks] Deleted watchlist %q", name)
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// AddWatchlistSymbol appends {"symbol": "NVDA"} to a watchlist
+func (h *Handler) AddWatchlistSymbol(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var body struct {
+		Symbol string `json:"symbol"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	watchlist, err := getWatchlist(name)
+	if err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	symbol, err := h.validateSymbol(body.Symbol)
+	if err != nil {
+		return symbolError(c, err)
+	}
+
+	for _, existing := range watchlist.Symbols {
+		if existing == symbol {
+			return c.JSON(watchlist)
+		}
+	}
+
+	if err := setWatchlistSymbols(name, append(watchlist.Symbols, symbol)); err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	invalidateCache(name)
+	return h.GetWatchlist(c)
+}
+
+// RemoveWatchlistSymbol removes a symbol from a watchlist
+func (h *Handler) RemoveWatchlistSymbol(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	watchlist, err := getWatchlist(name)
+	if err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	symbol := strings.ToUpper(symbolParam(c, "symbol"))
+	remaining := make([]string, 0, len(watchlist.Symbols))
+	for _, existing := range watchlist.Symbols {
+		if existing != symbol {
+			remaining = append(remaining, existing)
+		}
+	}
+	if len(remaining) == len(watchlist.Symbols) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": fmt.Sprintf("Symbol %q is not in watchlist %q", symbol, name),
+		})
+	}
+
+	if err := setWatchlistSymbols(name, remaining); err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	invalidateCache(name)
+	return h.GetWatchlist(c)
+}
+
+// ReorderWatchlistSymbols sets the symbol order from {"symbols": [...]},
+// which must contain exactly the watchlist's current symbols
+func (h *Handler) ReorderWatchlistSymbols(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var body struct {
+		Symbols []string `json:"symbols"`
+	}
+	if err := c.BodyParser(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid request body: %v", err),
+		})
+	}
+
+	watchlist, err := getWatchlist(name)
+	if err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	current := make(map[string]bool, len(watchlist.Symbols))
+	for _, symbol := range watchlist.Symbols {
+		current[symbol] = true
+	}
+
+	ordered := make([]string, 0, len(body.Symbols))
+	for _, raw := range body.Symbols {
+		symbol := strings.ToUpper(strings.TrimSpace(raw))
+		if !current[symbol] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Symbol %q is missing, duplicated or not in watchlist %q", symbol, name),
+			})
+		}
+		delete(current, symbol)
+		ordered = append(ordered, symbol)
+	}
+	if len(current) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Reorder must list all %d symbols of watchlist %q", len(watchlist.Symbols), name),
+		})
+	}
+
+	if err := setWatchlistSymbols(name, ordered); err != nil {
+		return watchlistError(c, name, err)
+	}
+
+	invalidateCache(name)
+	return h.GetWatchlist(c)
+}
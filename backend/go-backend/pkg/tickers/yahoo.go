@@ -2,14 +2,26 @@ package tickers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// DefaultYahooBaseURL is Yahoo Finance's unofficial chart API host
+const DefaultYahooBaseURL = "https://query1.finance.yahoo.com"
+
+// yahooCookieURL hands out the session cookie that the quoteSummary
+// endpoint requires along with a matching crumb
+const yahooCookieURL = "https://fc.yahoo.com"
+
 // UserAgents list to rotate through when making requests
 var UserAgents = []string{
 	// Chrome
@@ -29,123 +41,517 @@ var UserAgents = []string{
 	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36 Edg/131.0.2903.86",
 }
 
-type UserAgent struct {
-	sync.Once
-	name string
+var (
+	agentMu   sync.Mutex
+	agentName string
+)
+
+// userAgent returns the User-Agent for Yahoo requests, picking one on first use
+func userAgent() string {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+
+	if agentName == "" {
+		agentName = UserAgents[rand.Intn(len(UserAgents))]
+	}
+	return agentName
+}
+
+// rotateUserAgent switches to a different User-Agent after being rate limited
+func rotateUserAgent() {
+	agentMu.Lock()
+	defer agentMu.Unlock()
+
+	for {
+		next := UserAgents[rand.Intn(len(UserAgents))]
+		if next != agentName || len(UserAgents) == 1 {
+			agentName = next
+			return
+		}
+	}
+}
+
+// YahooProvider fetches market data from Yahoo's chart endpoint
+type YahooProvider struct {
+	baseURL   string
+	cookieURL string
+	client    *http.Client
+
+	// Session for the quoteSummary endpoint, fetched on first use
+	sessionMu sync.Mutex
+	cookie    string
+	crumb     string
 }
 
-var agent UserAgent
+// NewYahooProvider creates a Yahoo provider. An empty baseURL uses
+// DefaultYahooBaseURL.
+func NewYahooProvider(baseURL string, client *http.Client) *YahooProvider {
+	if baseURL == "" {
+		baseURL = DefaultYahooBaseURL
+	}
+	return &YahooProvider{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		cookieURL: yahooCookieURL,
+		client:    client,
+	}
+}
 
-func selectAgent() {
-	agent.name = UserAgents[rand.Intn(len(UserAgents))]
+func (y *YahooProvider) Name() string {
+	return "yahoo"
 }
 
 type YahooFinanceResponse struct {
 	Chart struct {
-		Result []struct {
-			Meta struct {
-				RegularMarketPrice   float64 `json:"regularMarketPrice"`
-				ChartPreviousClose   float64 `json:"chartPreviousClose"`
-				Currency             string  `json:"currency"`
-				Symbol               string  `json:"symbol"`
-				RegularMarketTime    int64   `json:"regularMarketTime"`
-				RegularMarketDayHigh float64 `json:"regularMarketDayHigh"`
-				RegularMarketDayLow  float64 `json:"regularMarketDayLow"`
-				RegularMarketVolume  float64 `json:"regularMarketVolume"`
-			} `json:"meta"`
-			Timestamp []int64 `json:"timestamp"`
-		} `json:"result"`
-		Error interface{} `json:"error"`
+		Result []YahooChartResult `json:"result"`
+		Error  interface{}        `json:"error"`
 	} `json:"chart"`
 }
 
-func fetchTickerData(ticker string) (*TickerData, error) {
-	// Get day data
-	dayData, err := fetchYahooData(ticker, "1d", "1d")
+type YahooChartResult struct {
+	Meta struct {
+		RegularMarketPrice   float64 `json:"regularMarketPrice"`
+		ChartPreviousClose   float64 `json:"chartPreviousClose"`
+		PreviousClose        float64 `json:"previousClose"`
+		Currency             string  `json:"currency"`
+		Symbol               string  `json:"symbol"`
+		RegularMarketTime    int64   `json:"regularMarketTime"`
+		RegularMarketDayHigh float64 `json:"regularMarketDayHigh"`
+		RegularMarketDayLow  float64 `json:"regularMarketDayLow"`
+		RegularMarketVolume  float64 `json:"regularMarketVolume"`
+		GmtOffset            int     `json:"gmtoffset"`
+		// Bounds of the latest trading day's sessions, in Unix seconds
+		CurrentTradingPeriod struct {
+			Pre     yahooTradingPeriod `json:"pre"`
+			Regular yahooTradingPeriod `json:"regular"`
+			Post    yahooTradingPeriod `json:"post"`
+		} `json:"currentTradingPeriod"`
+	} `json:"meta"`
+	Timestamp []int64 `json:"timestamp"`
+	// Events is only filled when requested with events=div,split,earn
+	Events struct {
+		Dividends map[string]struct {
+			Amount float64 `json:"amount"`
+			Date   int64   `json:"date"`
+		} `json:"dividends"`
+		Splits map[string]struct {
+			Date        int64   `json:"date"`
+			Numerator   float64 `json:"numerator"`
+			Denominator float64 `json:"denominator"`
+			SplitRatio  string  `json:"splitRatio"`
+		} `json:"splits"`
+		Earnings map[string]struct {
+			Date int64 `json:"date"`
+		} `json:"earnings"`
+	} `json:"events"`
+	Indicators struct {
+		// Values are null for bars where the exchange reported no trade
+		Quote []struct {
+			Open   []*float64 `json:"open"`
+			High   []*float64 `json:"high"`
+			Low    []*float64 `json:"low"`
+			Close  []*float64 `json:"close"`
+			Volume []*float64 `json:"volume"`
+		} `json:"quote"`
+	} `json:"indicators"`
+}
+
+type yahooTradingPeriod struct {
+	Start int64 `json:"start"`
+	End   int64 `json:"end"`
+}
+
+// Points converts the parallel timestamp and quote arrays into a series,
+// skipping bars without a close
+func (r *YahooChartResult) Points() []PricePoint {
+	if len(r.Indicators.Quote) == 0 {
+		return nil
+	}
+	quote := r.Indicators.Quote[0]
+
+	value := func(values []*float64, i int) float64 {
+		if i < len(values) && values[i] != nil {
+			return *values[i]
+		}
+		return 0
+	}
+
+	points := make([]PricePoint, 0, len(r.Timestamp))
+	for i, ts := range r.Timestamp {
+		if i >= len(quote.Close) || quote.Close[i] == nil {
+			continue
+		}
+		points = append(points, PricePoint{
+			Time:   ts,
+			Open:   value(quote.Open, i),
+			High:   value(quote.High, i),
+			Low:    value(quote.Low, i),
+			Close:  *quote.Close[i],
+			Volume: int64(value(quote.Volume, i)),
+		})
+	}
+	return points
+}
+
+// toQuote converts the chart metadata into a quote
+func (r *YahooChartResult) toQuote() *Quote {
+	meta := r.Meta
+	previousClose := meta.PreviousClose
+	if previousClose == 0 {
+		previousClose = meta.ChartPreviousClose
+	}
+	return &Quote{
+		Symbol:        meta.Symbol,
+		Price:         meta.RegularMarketPrice,
+		PreviousClose: previousClose,
+		Currency:      meta.Currency,
+		MarketTime:    time.Unix(meta.RegularMarketTime, 0).In(time.FixedZone("", meta.GmtOffset)),
+		DayHigh:       meta.RegularMarketDayHigh,
+		DayLow:        meta.RegularMarketDayLow,
+		Volume:        int64(meta.RegularMarketVolume),
+	}
+}
+
+// lastCloseBetween returns the close of the last bar in [start, end), or 0
+func (r *YahooChartResult) lastCloseBetween(start, end int64) float64 {
+	price := 0.0
+	for _, point := range r.Points() {
+		if point.Time >= start && point.Time < end {
+			price = point.Close
+		}
+	}
+	return price
+}
+
+// Quote fetches the latest quote using a one-day chart
+func (y *YahooProvider) Quote(symbol string) (*Quote, error) {
+	query := url.Values{}
+	query.Set("range", "1d")
+	query.Set("interval", IntervalDay)
+
+	result, err := y.fetchChart(symbol, query)
 	if err != nil {
 		return nil, err
 	}
+	return result.toQuote(), nil
+}
+
+// History fetches bars between from and to. The chart metadata carries the
+// latest quote, so it is returned with the series.
+func (y *YahooProvider) History(symbol string, from, to time.Time, interval string) (*Series, error) {
+	query := url.Values{}
+	query.Set("period1", strconv.FormatInt(from.Unix(), 10))
+	query.Set("period2", strconv.FormatInt(to.Unix(), 10))
+	query.Set("interval", interval)
 
-	// Get week data
-	weekData, err := fetchYahooData(ticker, "5d", "1d")
+	result, err := y.fetchChart(symbol, query)
 	if err != nil {
 		return nil, err
 	}
 
-	// Get year data
-	yearData, err := fetchYahooData(ticker, "1y", "1d")
+	return &Series{
+		Symbol:   symbol,
+		Currency: result.Meta.Currency,
+		Interval: interval,
+		Points:   result.Points(),
+		Quote:    result.toQuote(),
+	}, nil
+}
+
+// ExtendedHours takes the latest pre- and post-market prices from a one-day
+// chart of minute bars that includes extended-hours trading. The chart
+// metadata has no such prices, only the bounds of each session.
+func (y *YahooProvider) ExtendedHours(symbol string) (*ExtendedHoursPrices, error) {
+	query := url.Values{}
+	query.Set("range", "1d")
+	query.Set("interval", "1m")
+	query.Set("includePrePost", "true")
+
+	result, err := y.fetchChart(symbol, query)
 	if err != nil {
 		return nil, err
 	}
 
-	currentPrice := dayData.Chart.Result[0].Meta.RegularMarketPrice
-	dayChange := calculatePercentChange(currentPrice, dayData.Chart.Result[0].Meta.ChartPreviousClose)
-	weekChange := calculatePercentChange(currentPrice, weekData.Chart.Result[0].Meta.ChartPreviousClose)
-	yearChange := calculatePercentChange(currentPrice, yearData.Chart.Result[0].Meta.ChartPreviousClose)
-
-	return &TickerData{
-		Ticker:      ticker,
-		TodaysPrice: &currentPrice,
-		DayChange:   &dayChange,
-		WeekChange:  &weekChange,
-		YearChange:  &yearChange,
+	period := result.Meta.CurrentTradingPeriod
+	return &ExtendedHoursPrices{
+		Pre:  result.lastCloseBetween(period.Pre.Start, period.Pre.End),
+		Post: result.lastCloseBetween(period.Post.Start, period.Post.End),
 	}, nil
 }
 
-func fetchYahooData(ticker, timeRange, interval string) (*YahooFinanceResponse, error) {
-	url := "https://query1.finance.yahoo.com/v8/finance/chart/" + ticker + "?range=" + timeRange + "&interval=" + interval
+// Events fetches dividends, splits and earnings dates. The chart's event
+// data only holds events that already happened, so the announced next
+// ex-dividend and earnings dates come from the quoteSummary calendar.
+// Dates are reported in the exchange's timezone.
+func (y *YahooProvider) Events(symbol string, from, to time.Time) ([]Event, error) {
+	events, err := y.pastEvents(symbol, from, to)
+	if err != nil {
+		return nil, err
+	}
 
-	// Log the URL being requested
-	log.Printf("[Stocks] Requesting URL: %s", url)
+	upcoming, err := y.calendarEvents(symbol)
+	if err != nil {
+		// Past events are still worth storing
+		log.Printf("[Stocks] Failed to fetch upcoming events for %s: %v", symbol, err)
+		return events, nil
+	}
+	// The chart's version of a dividend carries its amount, so it wins
+	seen := make(map[string]bool, len(events))
+	for _, event := range events {
+		seen[event.Type+" "+event.Date] = true
+	}
+	fromDate := from.In(tradingDayLocation).Format("2006-01-02")
+	toDate := to.In(tradingDayLocation).Format("2006-01-02")
+	for _, event := range upcoming {
+		if event.Date >= fromDate && event.Date <= toDate && !seen[event.Type+" "+event.Date] {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
 
-	// Create a new request with User-Agent header
-	req, err := http.NewRequest("GET", url, nil)
+// pastEvents reads the dividends, splits and earnings of the chart's event data
+func (y *YahooProvider) pastEvents(symbol string, from, to time.Time) ([]Event, error) {
+	query := url.Values{}
+	query.Set("period1", strconv.FormatInt(from.Unix(), 10))
+	query.Set("period2", strconv.FormatInt(to.Unix(), 10))
+	query.Set("interval", IntervalDay)
+	query.Set("events", "div,split,earn")
+
+	result, err := y.fetchChart(symbol, query)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %v", err)
+		return nil, err
 	}
 
-	// Initialize the User-Agent once and reuse it
-	agent.Do(func() { selectAgent() })
-	req.Header.Add("User-Agent", agent.name)
+	zone := time.FixedZone("", result.Meta.GmtOffset)
+	date := func(ts int64) string {
+		return time.Unix(ts, 0).In(zone).Format("2006-01-02")
+	}
 
-	// Log the User-Agent being used
-	log.Printf("[Stocks] Using User-Agent: %s", agent.name)
+	events := make([]Event, 0)
+	for _, dividend := range result.Events.Dividends {
+		amount := dividend.Amount
+		events = append(events, Event{
+			Symbol:   symbol,
+			Type:     EventDividend,
+			Date:     date(dividend.Date),
+			Amount:   &amount,
+			Currency: result.Meta.Currency,
+		})
+	}
+	for _, split := range result.Events.Splits {
+		ratio := split.SplitRatio
+		if ratio == "" {
+			ratio = fmt.Sprintf("%g:%g", split.Numerator, split.Denominator)
+		}
+		events = append(events, Event{
+			Symbol: symbol,
+			Type:   EventSplit,
+			Date:   date(split.Date),
+			Ratio:  ratio,
+		})
+	}
+	for _, earnings := range result.Events.Earnings {
+		events = append(events, Event{
+			Symbol: symbol,
+			Type:   EventEarnings,
+			Date:   date(earnings.Date),
+		})
+	}
+	return events, nil
+}
+
+// yahooDate is a quoteSummary date, e.g. {"raw": 1793232000, "fmt": "2026-10-29"}
+type yahooDate struct {
+	Raw int64  `json:"raw"`
+	Fmt string `json:"fmt"`
+}
 
-	// Make the request with the configured headers
-	resp, err := http.DefaultClient.Do(req)
+type yahooQuoteSummaryResponse struct {
+	QuoteSummary struct {
+		Result []struct {
+			CalendarEvents struct {
+				Earnings struct {
+					// One date once announced, otherwise the estimated range
+					EarningsDate []yahooDate `json:"earningsDate"`
+				} `json:"earnings"`
+				ExDividendDate *yahooDate `json:"exDividendDate"`
+			} `json:"calendarEvents"`
+		} `json:"result"`
+		Error interface{} `json:"error"`
+	} `json:"quoteSummary"`
+}
+
+// calendarEvents fetches the next earnings date and the latest announced
+// ex-dividend date from the quoteSummary calendarEvents module
+func (y *YahooProvider) calendarEvents(symbol string) ([]Event, error) {
+	data, err := y.fetchQuoteSummary(symbol, "calendarEvents")
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch data: %v", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode == http.StatusTooManyRequests {
-		// If we hit rate limit, extend cache time
-		log.Printf("[Stocks] Rate limited by Yahoo API. Extending cache time.")
-		ExtendCacheTime(30 * time.Minute)
+	if data.QuoteSummary.Error != nil {
+		return nil, fmt.Errorf("API error: %v", data.QuoteSummary.Error)
+	}
+	if len(data.QuoteSummary.Result) == 0 {
+		return nil, fmt.Errorf("%w: no calendar returned for ticker %s", ErrSymbolNotFound, symbol)
+	}
+	calendar := data.QuoteSummary.Result[0].CalendarEvents
 
-		// Sleep before retry
-		time.Sleep(2 * time.Second)
+	var events []Event
+	if dates := calendar.Earnings.EarningsDate; len(dates) > 0 && validDate(dates[0].Fmt) {
+		events = append(events, Event{Symbol: symbol, Type: EventEarnings, Date: dates[0].Fmt})
+	}
+	if ex := calendar.ExDividendDate; ex != nil && validDate(ex.Fmt) {
+		// The calendar has no amount; it is filled in once the chart
+		// reports the paid dividend
+		events = append(events, Event{Symbol: symbol, Type: EventDividend, Date: ex.Fmt})
+	}
+	return events, nil
+}
 
-		// Log retry attempt with URL
-		log.Printf("[Stocks] Retrying request to: %s", url)
+func validDate(date string) bool {
+	_, err := time.Parse("2006-01-02", date)
+	return err == nil
+}
 
-		// Create a new request for the retry with possibly a different User-Agent
-		retryReq, err := http.NewRequest("GET", url, nil)
+// fetchQuoteSummary requests quoteSummary modules. A rejected session is
+// renewed once, as cookies and crumbs expire.
+func (y *YahooProvider) fetchQuoteSummary(symbol, modules string) (*yahooQuoteSummaryResponse, error) {
+	for attempt := 0; ; attempt++ {
+		cookie, crumb, err := y.session()
 		if err != nil {
-			return nil, fmt.Errorf("failed to create retry request: %v", err)
+			return nil, err
 		}
 
-		// Select a different User-Agent for the retry
-		agent.name = UserAgents[rand.Intn(len(UserAgents))]
-		retryReq.Header.Add("User-Agent", agent.name)
-		log.Printf("[Stocks] Retry using User-Agent: %s", agent.name)
-
-		// Try again with the new request
-		resp, err = http.DefaultClient.Do(retryReq)
+		query := url.Values{}
+		query.Set("modules", modules)
+		query.Set("crumb", crumb)
+		resp, err := y.get(y.baseURL+"/v10/finance/quoteSummary/"+url.PathEscape(symbol)+"?"+query.Encode(), cookie)
 		if err != nil {
-			return nil, fmt.Errorf("failed to fetch data after retry: %v", err)
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusUnauthorized && attempt == 0 {
+			resp.Body.Close()
+			y.resetSession()
+			continue
 		}
 		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, symbol)
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		}
+
+		var data yahooQuoteSummaryResponse
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %v", err)
+		}
+		return &data, nil
+	}
+}
+
+// session returns the cookie and crumb for quoteSummary requests, fetching
+// them on first use
+func (y *YahooProvider) session() (cookie, crumb string, err error) {
+	y.sessionMu.Lock()
+	defer y.sessionMu.Unlock()
+
+	if y.crumb != "" {
+		return y.cookie, y.crumb, nil
+	}
+
+	// The cookie host answers 404 but still sets the session cookie
+	resp, err := y.get(y.cookieURL, "")
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch session cookie: %w", err)
+	}
+	resp.Body.Close()
+	var pairs []string
+	for _, c := range resp.Cookies() {
+		pairs = append(pairs, c.Name+"="+c.Value)
+	}
+	if len(pairs) == 0 {
+		return "", "", fmt.Errorf("no session cookie returned by %s", y.cookieURL)
+	}
+	cookie = strings.Join(pairs, "; ")
+
+	resp, err = y.get(y.baseURL+"/v1/test/getcrumb", cookie)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch crumb: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read crumb: %w", err)
+	}
+	crumb = strings.TrimSpace(string(body))
+	if resp.StatusCode != http.StatusOK || crumb == "" {
+		return "", "", fmt.Errorf("failed to fetch crumb: status code %d", resp.StatusCode)
+	}
+
+	y.cookie, y.crumb = cookie, crumb
+	return cookie, crumb, nil
+}
+
+// resetSession drops a session Yahoo no longer accepts
+func (y *YahooProvider) resetSession() {
+	y.sessionMu.Lock()
+	defer y.sessionMu.Unlock()
+	y.cookie, y.crumb = "", ""
+}
+
+// get requests a Yahoo URL through the shared rate limiter, sending cookie
+// when it is set
+func (y *YahooProvider) get(requestURL, cookie string) (*http.Response, error) {
+	// Log the URL being requested
+	log.Printf("[Stocks] Requesting URL: %s", requestURL)
+
+	// Create a new request with User-Agent header
+	req, err := http.NewRequest("GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+
+	name := userAgent()
+	req.Header.Add("User-Agent", name)
+	if cookie != "" {
+		req.Header.Add("Cookie", cookie)
+	}
+
+	// Log the User-Agent being used
+	log.Printf("[Stocks] Using User-Agent: %s", name)
+
+	// Make the request through the shared rate limiter
+	resp, err := limiter.do(y.client, req)
+	if errors.Is(err, ErrRateLimited) {
+		// Present as a different browser once the backoff is over. Only
+		// Yahoo's own 429s count; the limiter holding back says nothing
+		// about the current User-Agent.
+		if errors.Is(err, ErrTooManyRequests) {
+			rotateUserAgent()
+		}
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch data: %w", err)
+	}
+	return resp, nil
+}
+
+func (y *YahooProvider) fetchChart(ticker string, query url.Values) (*YahooChartResult, error) {
+	requestURL := y.baseURL + "/v8/finance/chart/" + url.PathEscape(ticker) + "?" + query.Encode()
+
+	resp, err := y.get(requestURL, "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// Yahoo answers unknown symbols with 404 and an error body
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrSymbolNotFound, ticker)
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -162,15 +568,8 @@ func fetchYahooData(ticker, timeRange, interval string) (*YahooFinanceResponse,
 	}
 
 	if len(data.Chart.Result) == 0 {
-		return nil, fmt.Errorf("no data returned for ticker %s", ticker)
+		return nil, fmt.Errorf("%w: no data returned for ticker %s", ErrSymbolNotFound, ticker)
 	}
 
-	return &data, nil
-}
-
-func calculatePercentChange(current, previous float64) float64 {
-	if previous == 0 {
-		return 0
-	}
-	return ((current - previous) / previous) * 100
+	return &data.Chart.Result[0], nil
 }
@@ -0,0 +1,109 @@
+package tickers
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestYahooExtendedHoursTakesLastBarOfEachSession(t *testing.T) {
+	fixture, err := os.ReadFile("testdata/yahoo_chart_prepost.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(fixture)
+	}))
+	defer server.Close()
+
+	prices, err := NewYahooProvider(server.URL, server.Client()).ExtendedHours("AAPL")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "includePrePost=true&interval=1m&range=1d"; query != want {
+		t.Errorf("query = %q, want %q", query, want)
+	}
+	// The last pre-market bar is 09:29; the 19:58 bar has no close, so
+	// 16:05 is the last post-market trade
+	if prices.Pre != 250.15 {
+		t.Errorf("pre = %v, want 250.15", prices.Pre)
+	}
+	if prices.Post != 251.35 {
+		t.Errorf("post = %v, want 251.35", prices.Post)
+	}
+}
+
+func TestYahooEventsAddsUpcomingCalendarDates(t *testing.T) {
+	calendar, err := os.ReadFile("testdata/yahoo_quotesummary_calendar.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A paid dividend, as the chart reports it with events=div,split,earn
+	chart := `{"chart":{"result":[{"meta":{"currency":"USD","symbol":"SCHD","gmtoffset":-14400},
+		"timestamp":[1784122200],"indicators":{"quote":[{"close":[27.1]}]},
+		"events":{"dividends":{"1784122200":{"amount":0.26,"date":1784122200}}}}],"error":null}}`
+
+	var sessions, summaries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/cookie":
+			sessions++
+			http.SetCookie(w, &http.Cookie{Name: "A3", Value: fmt.Sprintf("session%d", sessions)})
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1/test/getcrumb":
+			fmt.Fprintf(w, "crumb-%s", strings.TrimPrefix(r.Header.Get("Cookie"), "A3="))
+		case strings.HasPrefix(r.URL.Path, "/v8/finance/chart/"):
+			fmt.Fprint(w, chart)
+		case strings.HasPrefix(r.URL.Path, "/v10/finance/quoteSummary/"):
+			summaries++
+			// The first session has expired and must be renewed
+			if r.URL.Query().Get("crumb") != "crumb-session2" || r.Header.Get("Cookie") != "A3=session2" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write(calendar)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewYahooProvider(server.URL, server.Client())
+	provider.cookieURL = server.URL + "/cookie"
+
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	events, err := provider.Events("SCHD", from, from.AddDate(1, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sessions != 2 || summaries != 2 {
+		t.Errorf("got %d sessions and %d quoteSummary requests, want 2 and 2", sessions, summaries)
+	}
+
+	got := make(map[string]Event)
+	for _, event := range events {
+		got[event.Type+" "+event.Date] = event
+	}
+	if len(got) != 3 {
+		t.Fatalf("got events %+v, want a past dividend, an ex-dividend date and an earnings date", events)
+	}
+	if paid := got[EventDividend+" 2026-07-15"]; paid.Amount == nil || *paid.Amount != 0.26 {
+		t.Errorf("paid dividend = %+v, want amount 0.26", paid)
+	}
+	if _, ok := got[EventDividend+" 2026-12-10"]; !ok {
+		t.Errorf("missing upcoming ex-dividend date in %+v", events)
+	}
+	if _, ok := got[EventEarnings+" 2026-10-29"]; !ok {
+		t.Errorf("missing upcoming earnings date in %+v", events)
+	}
+}